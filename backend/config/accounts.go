@@ -0,0 +1,13 @@
+package config
+
+// AccountPurgeDays is how long a deleted account's anonymized records are kept before the purge job
+// removes what is left of them (ACCOUNT_PURGE_DAYS, default 30)
+func AccountPurgeDays() int {
+	return envInt("ACCOUNT_PURGE_DAYS", 30)
+}
+
+// AccountStatusCacheSeconds is how long the auth middleware trusts a looked-up account status before
+// checking the database again (ACCOUNT_STATUS_CACHE_SECONDS, default 30)
+func AccountStatusCacheSeconds() int {
+	return envInt("ACCOUNT_STATUS_CACHE_SECONDS", 30)
+}
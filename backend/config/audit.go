@@ -0,0 +1,24 @@
+package config
+
+import "log"
+
+// migrateAuditLog installs a trigger that rejects updates and deletes on audit_logs, so entries can't be
+// rewritten even by code that goes around the audit service
+func migrateAuditLog() {
+	statements := []string{
+		`CREATE OR REPLACE FUNCTION audit_logs_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'audit_logs is append-only';
+END;
+$$ LANGUAGE plpgsql`,
+		`DROP TRIGGER IF EXISTS audit_logs_append_only ON audit_logs`,
+		`CREATE TRIGGER audit_logs_append_only BEFORE UPDATE OR DELETE ON audit_logs
+	FOR EACH ROW EXECUTE FUNCTION audit_logs_append_only()`,
+	}
+	for _, statement := range statements {
+		if err := DB.Exec(statement).Error; err != nil {
+			log.Printf("Failed to make audit_logs append-only: %v", err)
+			return
+		}
+	}
+}
@@ -1,12 +1,25 @@
 package config
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/sas"
 )
 
+// ExportsContainer is the private container holding user data exports (see terraform/main.tf)
+const ExportsContainer = "exports"
+
+// DataExportTTL is how long a data export can be downloaded (DATA_EXPORT_TTL_HOURS, default 48)
+func DataExportTTL() time.Duration {
+	return time.Duration(envInt("DATA_EXPORT_TTL_HOURS", 48)) * time.Hour
+}
+
 var BlobClient *azblob.Client
 
 func ConnectAzureBlobStorage() {
@@ -31,3 +44,35 @@ func GetBlobContainerURL() string {
 	accountName := os.Getenv("AZURE_STORAGE_ACCOUNT_NAME")
 	return fmt.Sprintf("https://%s.blob.core.windows.net", accountName)
 }
+
+// UploadBlob stores data under name in a container
+func UploadBlob(containerName, blobName string, data []byte, contentType string) error {
+	if BlobClient == nil {
+		return fmt.Errorf("Azure Blob Storage client is not initialized")
+	}
+	_, err := BlobClient.UploadBuffer(context.Background(), containerName, blobName, data, &azblob.UploadBufferOptions{
+		HTTPHeaders: &blob.HTTPHeaders{BlobContentType: &contentType},
+	})
+	return err
+}
+
+// DeleteBlob removes a blob; deleting one that is already gone is not an error
+func DeleteBlob(containerName, blobName string) error {
+	if BlobClient == nil {
+		return fmt.Errorf("Azure Blob Storage client is not initialized")
+	}
+	_, err := BlobClient.DeleteBlob(context.Background(), containerName, blobName, nil)
+	if bloberror.HasCode(err, bloberror.BlobNotFound) {
+		return nil
+	}
+	return err
+}
+
+// BlobSASURL returns a read-only link to a blob in a private container that stops working at expiry
+func BlobSASURL(containerName, blobName string, expiry time.Time) (string, error) {
+	if BlobClient == nil {
+		return "", fmt.Errorf("Azure Blob Storage client is not initialized")
+	}
+	blobClient := BlobClient.ServiceClient().NewContainerClient(containerName).NewBlobClient(blobName)
+	return blobClient.GetSASURL(sas.BlobPermissions{Read: true}, expiry, nil)
+}
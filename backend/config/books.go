@@ -0,0 +1,174 @@
+package config
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed data/books.json
+var bundledBookCatalog []byte
+
+// ErrBookNotFound is returned by a BookMetadataProvider that has no record for an ISBN
+var ErrBookNotFound = errors.New("book not found")
+
+// BookMetadata describes a book found by ISBN
+type BookMetadata struct {
+	ISBN        string   `json:"isbn"` // ISBN-13
+	Title       string   `json:"title"`
+	Authors     []string `json:"authors"`
+	Publisher   string   `json:"publisher,omitempty"`
+	Edition     int      `json:"edition,omitempty"`
+	Year        int      `json:"year,omitempty"`
+	CourseCodes []string `json:"course_codes,omitempty"`
+	CoverURL    string   `json:"cover_url,omitempty"`
+	Source      string   `json:"source"`
+}
+
+// openLibraryCoverURL is the Open Library covers API image for an ISBN
+func openLibraryCoverURL(isbn string) string {
+	return "https://covers.openlibrary.org/b/isbn/" + isbn + "-L.jpg"
+}
+
+// BookMetadataProvider looks books up by normalized ISBN-13
+type BookMetadataProvider interface {
+	LookupISBN(isbn string) (*BookMetadata, error)
+}
+
+// Books is the configured metadata provider; the bundled catalog is always available
+var Books BookMetadataProvider = mustLoadLocalBookCatalog()
+
+// ConnectBookMetadata picks the metadata provider from BOOK_METADATA_PROVIDER ("local" or "openlibrary").
+// Remote providers are consulted only after the bundled catalog.
+func ConnectBookMetadata() {
+	local := mustLoadLocalBookCatalog()
+
+	switch os.Getenv("BOOK_METADATA_PROVIDER") {
+	case "openlibrary":
+		Books = ChainBookProviders{local, &OpenLibraryProvider{Client: &http.Client{Timeout: 5 * time.Second}}}
+		fmt.Println("Book metadata from the bundled catalog and Open Library")
+	default:
+		Books = local
+		fmt.Printf("Book metadata from the bundled catalog (%d books)\n", len(local.books))
+	}
+}
+
+// LocalBookCatalog serves metadata from the catalog compiled into the binary
+type LocalBookCatalog struct {
+	books map[string]BookMetadata
+}
+
+func mustLoadLocalBookCatalog() *LocalBookCatalog {
+	var books []BookMetadata
+	if err := json.Unmarshal(bundledBookCatalog, &books); err != nil {
+		panic(fmt.Sprintf("invalid bundled book catalog: %v", err))
+	}
+
+	catalog := &LocalBookCatalog{books: make(map[string]BookMetadata, len(books))}
+	for _, book := range books {
+		book.Source = "catalog"
+		if book.CoverURL == "" {
+			book.CoverURL = openLibraryCoverURL(book.ISBN)
+		}
+		catalog.books[book.ISBN] = book
+	}
+	return catalog
+}
+
+func (c *LocalBookCatalog) LookupISBN(isbn string) (*BookMetadata, error) {
+	book, ok := c.books[isbn]
+	if !ok {
+		return nil, ErrBookNotFound
+	}
+	return &book, nil
+}
+
+// ChainBookProviders tries each provider in turn until one knows the book
+type ChainBookProviders []BookMetadataProvider
+
+func (chain ChainBookProviders) LookupISBN(isbn string) (*BookMetadata, error) {
+	var lastErr error = ErrBookNotFound
+	for _, provider := range chain {
+		book, err := provider.LookupISBN(isbn)
+		if err == nil {
+			return book, nil
+		}
+		if !errors.Is(err, ErrBookNotFound) {
+			lastErr = err
+		}
+	}
+	return nil, lastErr
+}
+
+// OpenLibraryProvider looks books up through the Open Library books API
+type OpenLibraryProvider struct {
+	Client *http.Client
+}
+
+var editionNumber = regexp.MustCompile(`\d+`)
+
+func (p *OpenLibraryProvider) LookupISBN(isbn string) (*BookMetadata, error) {
+	key := "ISBN:" + isbn
+	resp, err := p.Client.Get("https://openlibrary.org/api/books?format=json&jscmd=data&bibkeys=" + url.QueryEscape(key))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("open library returned %s", resp.Status)
+	}
+
+	var result map[string]struct {
+		Title       string `json:"title"`
+		PublishDate string `json:"publish_date"`
+		EditionName string `json:"edition_name"`
+		Authors     []struct {
+			Name string `json:"name"`
+		} `json:"authors"`
+		Publishers []struct {
+			Name string `json:"name"`
+		} `json:"publishers"`
+		Cover struct {
+			Medium string `json:"medium"`
+			Large  string `json:"large"`
+		} `json:"cover"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	data, ok := result[key]
+	if !ok {
+		return nil, ErrBookNotFound
+	}
+
+	book := &BookMetadata{ISBN: isbn, Title: data.Title, Authors: []string{}, Source: "openlibrary"}
+	for _, author := range data.Authors {
+		book.Authors = append(book.Authors, author.Name)
+	}
+	if len(data.Publishers) > 0 {
+		book.Publisher = data.Publishers[0].Name
+	}
+	if fields := strings.Fields(data.PublishDate); len(fields) > 0 {
+		book.Year, _ = strconv.Atoi(fields[len(fields)-1])
+	}
+	if match := editionNumber.FindString(data.EditionName); match != "" {
+		book.Edition, _ = strconv.Atoi(match)
+	}
+	switch {
+	case data.Cover.Large != "":
+		book.CoverURL = data.Cover.Large
+	case data.Cover.Medium != "":
+		book.CoverURL = data.Cover.Medium
+	default:
+		book.CoverURL = openLibraryCoverURL(isbn)
+	}
+	return book, nil
+}
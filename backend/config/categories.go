@@ -0,0 +1,269 @@
+package config
+
+import (
+	"encoding/json"
+	"log"
+	"strings"
+
+	"marketplace-backend/models"
+
+	"github.com/google/uuid"
+)
+
+// OtherCategorySlug is where listings go when their category can't be mapped
+const OtherCategorySlug = "other"
+
+type categorySeed struct {
+	Name       string
+	Slug       string
+	Attributes []models.AttributeDefinition
+	Template   string
+	Children   []categorySeed
+}
+
+func floatPtr(f float64) *float64 { return &f }
+
+var defaultCategories = []categorySeed{
+	{
+		Name:     "Electronics",
+		Slug:     "electronics",
+		Template: "Great %s in good condition, perfect for students who need reliable technology for their studies and daily use.",
+		Attributes: []models.AttributeDefinition{
+			{Key: "brand", Label: "Brand", Type: "string", Required: true},
+			{Key: "model", Label: "Model", Type: "string"},
+			{Key: "storage_gb", Label: "Storage (GB)", Type: "integer", Min: floatPtr(0)},
+		},
+		Children: []categorySeed{
+			{Name: "Laptops", Slug: "laptops"},
+			{Name: "Phones & Tablets", Slug: "phones-tablets"},
+			{Name: "Audio", Slug: "audio"},
+			{Name: "Accessories", Slug: "electronics-accessories"},
+		},
+	},
+	{
+		Name:     "Books",
+		Slug:     "books",
+		Template: "Well-maintained %s, ideal for students looking to save money on textbooks while getting quality educational materials.",
+		Children: []categorySeed{
+			{
+				Name: "Textbooks",
+				Slug: "textbooks",
+				Attributes: []models.AttributeDefinition{
+					{Key: "isbn", Label: "ISBN", Type: "string", Required: true},
+					{Key: "edition", Label: "Edition", Type: "integer", Required: true, Min: floatPtr(1)},
+					{Key: "course_code", Label: "Course code", Type: "string", Required: true},
+				},
+			},
+			{Name: "Notes & Study Guides", Slug: "study-guides"},
+		},
+	},
+	{
+		Name:     "Furniture",
+		Slug:     "furniture",
+		Template: "Functional %s in decent condition, perfect for dorm rooms or student apartments. Great value for money.",
+	},
+	{
+		Name:     "Appliances",
+		Slug:     "appliances",
+		Template: "Handy %s in working condition, a practical pick for dorm rooms and shared kitchens.",
+	},
+	{
+		Name:     "Clothing",
+		Slug:     "clothing",
+		Template: "Stylish %s in good condition, perfect for students looking to expand their wardrobe on a budget.",
+		Attributes: []models.AttributeDefinition{
+			{Key: "size", Label: "Size", Type: "enum", Options: []string{"XS", "S", "M", "L", "XL", "XXL"}},
+		},
+	},
+	{
+		Name:     "Sports",
+		Slug:     "sports",
+		Template: "Quality %s ready for action, great for students who want to stay active without breaking the bank.",
+	},
+	{Name: "Other", Slug: OtherCategorySlug},
+}
+
+// categoryAliases maps common free-text categories from before the taxonomy to slugs
+var categoryAliases = map[string]string{
+	"electronic":  "electronics",
+	"tech":        "electronics",
+	"gadgets":     "electronics",
+	"laptop":      "laptops",
+	"computer":    "laptops",
+	"computers":   "laptops",
+	"phone":       "phones-tablets",
+	"phones":      "phones-tablets",
+	"mobile":      "phones-tablets",
+	"tablet":      "phones-tablets",
+	"headphones":  "audio",
+	"book":        "books",
+	"textbook":    "textbooks",
+	"notes":       "study-guides",
+	"appliance":   "appliances",
+	"clothes":     "clothing",
+	"apparel":     "clothing",
+	"sport":       "sports",
+	"sports gear": "sports",
+	"misc":        OtherCategorySlug,
+}
+
+func seedDefaultCategories() {
+	var count int64
+	DB.Model(&models.Category{}).Count(&count)
+	if count > 0 {
+		return
+	}
+
+	if err := createCategorySeeds(defaultCategories, nil); err != nil {
+		log.Printf("Failed to seed categories: %v", err)
+		return
+	}
+	log.Println("Default categories created successfully!")
+}
+
+func createCategorySeeds(seeds []categorySeed, parentID *uuid.UUID) error {
+	for i, seed := range seeds {
+		schema, _ := json.Marshal(append([]models.AttributeDefinition{}, seed.Attributes...))
+		category := models.Category{
+			ParentID:            parentID,
+			Name:                seed.Name,
+			Slug:                seed.Slug,
+			AttributeSchema:     string(schema),
+			DescriptionTemplate: seed.Template,
+			SortOrder:           i,
+			IsActive:            true,
+		}
+		if err := DB.Create(&category).Error; err != nil {
+			return err
+		}
+		if err := createCategorySeeds(seed.Children, &category.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateCategoryAttributeSchemas fills in attribute schemas for categories created before they
+// existed: default categories get their seeded schema, others keep their old required attribute keys
+func migrateCategoryAttributeSchemas() {
+	var categories []models.Category
+	if err := DB.Where("attribute_schema IS NULL OR attribute_schema = ''").Find(&categories).Error; err != nil {
+		log.Printf("Failed to load categories for schema migration: %v", err)
+		return
+	}
+	if len(categories) == 0 {
+		return
+	}
+
+	seeds := map[string][]models.AttributeDefinition{}
+	var collect func([]categorySeed)
+	collect = func(list []categorySeed) {
+		for _, seed := range list {
+			seeds[seed.Slug] = seed.Attributes
+			collect(seed.Children)
+		}
+	}
+	collect(defaultCategories)
+
+	hasLegacyColumn := DB.Migrator().HasColumn(&models.Category{}, "required_attributes")
+	for _, category := range categories {
+		schema := append([]models.AttributeDefinition{}, seeds[category.Slug]...)
+		if _, isDefault := seeds[category.Slug]; !isDefault && hasLegacyColumn {
+			var legacy string
+			DB.Table("categories").Where("id = ?", category.ID).Select("COALESCE(required_attributes, '')").Row().Scan(&legacy)
+			var keys []string
+			json.Unmarshal([]byte(legacy), &keys)
+			for _, key := range keys {
+				schema = append(schema, models.AttributeDefinition{Key: key, Label: key, Type: "string", Required: true})
+			}
+		}
+
+		schemaJSON, _ := json.Marshal(schema)
+		if err := DB.Model(&category).Update("attribute_schema", string(schemaJSON)).Error; err != nil {
+			log.Printf("Failed to migrate attribute schema for category %s: %v", category.Slug, err)
+		}
+	}
+	log.Printf("Migrated attribute schemas for %d categories", len(categories))
+}
+
+// migrateProductCategories links products that only have a free-text category to the taxonomy,
+// matching on slug, name or a known alias and falling back to "Other"
+func migrateProductCategories() {
+	var values []string
+	if err := DB.Model(&models.Product{}).Where("category_id IS NULL").Distinct().Pluck("category", &values).Error; err != nil {
+		log.Printf("Failed to load product categories for migration: %v", err)
+		return
+	}
+	if len(values) == 0 {
+		return
+	}
+
+	var other models.Category
+	if err := DB.Where("slug = ?", OtherCategorySlug).First(&other).Error; err != nil {
+		log.Printf("Category migration skipped, %q category is missing: %v", OtherCategorySlug, err)
+		return
+	}
+
+	for _, value := range values {
+		category := FindCategory(value)
+		if category == nil {
+			category = &other
+		}
+
+		result := DB.Model(&models.Product{}).
+			Where("category_id IS NULL AND category = ?", value).
+			Updates(map[string]interface{}{"category_id": category.ID, "category": category.Name})
+		if result.Error != nil {
+			log.Printf("Failed to migrate category %q: %v", value, result.Error)
+			continue
+		}
+		log.Printf("Migrated %d products from category %q to %q", result.RowsAffected, value, category.Slug)
+	}
+}
+
+// FindCategory looks a category up by ID, slug, name or legacy alias; it returns nil if none match
+func FindCategory(ref string) *models.Category {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return nil
+	}
+
+	var category models.Category
+	if id, err := uuid.Parse(ref); err == nil {
+		if DB.First(&category, id).Error == nil {
+			return &category
+		}
+		return nil
+	}
+
+	key := strings.ToLower(ref)
+	if DB.Where("slug = ?", key).First(&category).Error == nil {
+		return &category
+	}
+	if DB.Where("LOWER(name) = ?", key).Order("parent_id IS NOT NULL, sort_order").First(&category).Error == nil {
+		return &category
+	}
+	if slug, ok := categoryAliases[key]; ok && DB.Where("slug = ?", slug).First(&category).Error == nil {
+		return &category
+	}
+	return nil
+}
+
+// CategoryDescriptionTemplate returns the closest description template up the category's ancestry
+func CategoryDescriptionTemplate(ref string) string {
+	category := FindCategory(ref)
+	for depth := 0; category != nil && depth < 10; depth++ {
+		if category.DescriptionTemplate != "" {
+			return category.DescriptionTemplate
+		}
+		if category.ParentID == nil {
+			break
+		}
+		var parent models.Category
+		if DB.First(&parent, *category.ParentID).Error != nil {
+			break
+		}
+		category = &parent
+	}
+	return ""
+}
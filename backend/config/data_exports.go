@@ -0,0 +1,23 @@
+package config
+
+import "log"
+
+// migrateDataExports allows one pending or processing export per user, so concurrent polls of
+// GET /api/users/me/export can't start two builds. Older duplicates are failed first so the index can be created.
+func migrateDataExports() {
+	statements := []string{
+		`UPDATE data_exports SET status = 'failed', error = 'The export was interrupted, try again'
+	WHERE status IN ('pending', 'processing') AND id NOT IN (
+		SELECT DISTINCT ON (user_id) id FROM data_exports
+		WHERE status IN ('pending', 'processing')
+		ORDER BY user_id, created_at DESC)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_data_exports_in_progress ON data_exports (user_id)
+	WHERE status IN ('pending', 'processing')`,
+	}
+	for _, statement := range statements {
+		if err := DB.Exec(statement).Error; err != nil {
+			log.Printf("Failed to limit data exports to one in progress per user: %v", err)
+			return
+		}
+	}
+}
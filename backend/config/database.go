@@ -75,6 +75,28 @@ func ConnectDatabase() {
 		&models.Message{},
 		&models.PurchaseRequest{},
 		&models.Favorite{},
+		&models.SafeExchangeSpot{},
+		&models.Meetup{},
+		&models.MeetupSlot{},
+		&models.Notification{},
+		&models.NotificationPreference{},
+		&models.EmailJob{},
+		&models.DigestCursor{},
+		&models.PushSubscription{},
+		&models.SavedSearch{},
+		&models.ProductPriceHistory{},
+		&models.WishlistCollection{},
+		&models.CollectionItem{},
+		&models.ProductView{},
+		&models.Category{},
+		&models.Tag{},
+		&models.ProductImport{},
+		&models.DataExport{},
+		&models.SellerReview{},
+		&models.Follow{},
+		&models.Block{},
+		&models.Report{},
+		&models.AuditLog{},
 	)
 
 	if err != nil {
@@ -97,8 +119,27 @@ func ConnectDatabase() {
 	// Seed default college if none exists
 	seedDefaultCollege()
 
+	// Seed the category taxonomy if it is empty
+	seedDefaultCategories()
+	migrateCategoryAttributeSchemas()
+
 	// Seed default products if none exist
 	seedDefaultProducts()
+
+	// Link products that still only have a free-text category
+	migrateProductCategories()
+
+	// Move tags from the old free-form column into product_tags
+	migrateProductTags()
+
+	// Give existing listings publish and expiry dates
+	migrateListingLifecycle()
+
+	// Keep the audit log append-only
+	migrateAuditLog()
+
+	// One data export in progress per user
+	migrateDataExports()
 }
 
 func seedDefaultCollege() {
@@ -0,0 +1,204 @@
+package config
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// EmailMessage is a rendered email ready to be delivered
+type EmailMessage struct {
+	To       string
+	Subject  string
+	HTMLBody string
+	TextBody string
+}
+
+// EmailSender delivers rendered emails
+type EmailSender interface {
+	Send(msg EmailMessage) error
+}
+
+// Mailer is the configured email sender, nil when email is disabled
+var Mailer EmailSender
+
+// ConnectEmailSender picks the email sender from EMAIL_SENDER ("smtp" or "file")
+func ConnectEmailSender() {
+	from := os.Getenv("EMAIL_FROM")
+	if from == "" {
+		from = "Campus Marketplace <no-reply@marketplace.local>"
+	}
+
+	switch os.Getenv("EMAIL_SENDER") {
+	case "smtp":
+		host := os.Getenv("SMTP_HOST")
+		if host == "" {
+			fmt.Println("SMTP_HOST not set. Email notifications disabled.")
+			return
+		}
+		port := os.Getenv("SMTP_PORT")
+		if port == "" {
+			port = "587"
+		}
+		Mailer = &SMTPSender{
+			Host:     host,
+			Port:     port,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     from,
+		}
+		fmt.Printf("Email notifications enabled via SMTP (%s:%s)\n", host, port)
+	case "file":
+		dir := os.Getenv("EMAIL_DROP_DIR")
+		if dir == "" {
+			dir = filepath.Join("temp", "emails")
+		}
+		Mailer = &FileDropSender{Dir: dir, From: from}
+		fmt.Printf("Email notifications written to %s\n", dir)
+	default:
+		fmt.Println("EMAIL_SENDER not configured. Email notifications disabled.")
+	}
+}
+
+// GetFrontendURL returns the public frontend URL used for links in outbound messages
+func GetFrontendURL() string {
+	if url := os.Getenv("FRONTEND_URL"); url != "" {
+		return strings.TrimRight(url, "/")
+	}
+	return "http://localhost:5173"
+}
+
+// SMTPSender delivers email through an SMTP relay using PLAIN auth
+type SMTPSender struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func (s *SMTPSender) Send(msg EmailMessage) error {
+	raw, err := buildMIMEMessage(s.From, msg)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	}
+
+	return smtp.SendMail(s.Host+":"+s.Port, auth, envelopeAddress(s.From), []string{msg.To}, raw)
+}
+
+// FileDropSender writes each email as an .eml file, for local development
+type FileDropSender struct {
+	Dir  string
+	From string
+}
+
+func (s *FileDropSender) Send(msg EmailMessage) error {
+	raw, err := buildMIMEMessage(s.From, msg)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(s.Dir, 0755); err != nil {
+		return fmt.Errorf("failed to create email drop directory: %v", err)
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405"), randomHex(4))
+	return os.WriteFile(filepath.Join(s.Dir, name), raw, 0644)
+}
+
+// buildMIMEMessage renders a multipart/alternative message with text and HTML parts
+func buildMIMEMessage(from string, msg EmailMessage) ([]byte, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	parts := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=UTF-8", msg.TextBody},
+		{"text/html; charset=UTF-8", msg.HTMLBody},
+	}
+	for _, p := range parts {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", p.contentType)
+		header.Set("Content-Transfer-Encoding", "quoted-printable")
+		w, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(p.content)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "From: %s\r\n", from)
+	fmt.Fprintf(&out, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&out, "Subject: %s\r\n", encodeHeader(msg.Subject))
+	fmt.Fprintf(&out, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&out, "Message-ID: <%s@marketplace>\r\n", randomHex(12))
+	out.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&out, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", writer.Boundary())
+	out.Write(body.Bytes())
+
+	return out.Bytes(), nil
+}
+
+// encodeHeader encodes non-ASCII header values as RFC 2047 words
+func encodeHeader(value string) string {
+	for _, r := range value {
+		if r > 127 {
+			return fmt.Sprintf("=?UTF-8?Q?%s?=", strings.ReplaceAll(qEncode(value), " ", "_"))
+		}
+	}
+	return value
+}
+
+func qEncode(value string) string {
+	var b strings.Builder
+	for _, c := range []byte(value) {
+		if c == ' ' || (c > 32 && c < 127 && c != '=' && c != '?' && c != '_') {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "=%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// envelopeAddress extracts the bare address from "Name <addr>"
+func envelopeAddress(from string) string {
+	if start := strings.Index(from, "<"); start >= 0 {
+		if end := strings.Index(from[start:], ">"); end > 0 {
+			return from[start+1 : start+end]
+		}
+	}
+	return from
+}
+
+func randomHex(n int) string {
+	buf := make([]byte, n)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
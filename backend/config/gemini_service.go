@@ -345,20 +345,14 @@ func processUploadedFile(fileHeader *multipart.FileHeader) (string, string, erro
 	return imageBase64, mimeType, nil
 }
 
-// GenerateTemplateDescription provides a fallback template-based description
+// GenerateTemplateDescription provides a fallback template-based description,
+// using the template of the closest category in the taxonomy
 func GenerateTemplateDescription(title, category string) string {
-	templates := map[string]string{
-		"Electronics":    "Great %s in good condition, perfect for students who need reliable technology for their studies and daily use.",
-		"Books":          "Well-maintained %s, ideal for students looking to save money on textbooks while getting quality educational materials.",
-		"Furniture":      "Functional %s in decent condition, perfect for dorm rooms or student apartments. Great value for money.",
-		"Clothing":       "Stylish %s in good condition, perfect for students looking to expand their wardrobe on a budget.",
-		"Sports":         "Quality %s ready for action, great for students who want to stay active without breaking the bank.",
-		"default":        "Quality %s in good condition, perfect for students looking for great value and reliable performance.",
-	}
-
-	template, exists := templates[category]
-	if !exists {
-		template = templates["default"]
+	template := "Quality %s in good condition, perfect for students looking for great value and reliable performance."
+	if DB != nil {
+		if categoryTemplate := CategoryDescriptionTemplate(category); categoryTemplate != "" {
+			template = categoryTemplate
+		}
 	}
 
 	return fmt.Sprintf(template, title)
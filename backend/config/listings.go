@@ -0,0 +1,72 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"marketplace-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// ListingExpiryDays is how long a published listing stays live before it expires (LISTING_EXPIRY_DAYS, default 30)
+func ListingExpiryDays() int {
+	return envInt("LISTING_EXPIRY_DAYS", 30)
+}
+
+// ListingExpiryReminderDays is how long before expiry the seller is reminded (LISTING_EXPIRY_REMINDER_DAYS, default 3)
+func ListingExpiryReminderDays() int {
+	return envInt("LISTING_EXPIRY_REMINDER_DAYS", 3)
+}
+
+// ListingBumpCooldown is the minimum time between relists of a live listing (LISTING_BUMP_COOLDOWN_HOURS, default 72)
+func ListingBumpCooldown() time.Duration {
+	return time.Duration(envInt("LISTING_BUMP_COOLDOWN_HOURS", 72)) * time.Hour
+}
+
+// SoldListingRetentionDays is how long sold listings stay visible before being archived (SOLD_LISTING_RETENTION_DAYS, default 30)
+func SoldListingRetentionDays() int {
+	return envInt("SOLD_LISTING_RETENTION_DAYS", 30)
+}
+
+// ProductRestoreWindowDays is how long a deleted listing can be restored by its seller (PRODUCT_RESTORE_WINDOW_DAYS, default 14)
+func ProductRestoreWindowDays() int {
+	return envInt("PRODUCT_RESTORE_WINDOW_DAYS", 14)
+}
+
+func envInt(name string, fallback int) int {
+	if value, err := strconv.Atoi(os.Getenv(name)); err == nil && value > 0 {
+		return value
+	}
+	return fallback
+}
+
+// migrateListingLifecycle backfills lifecycle timestamps for listings created before drafts and expiry existed.
+// Live listings get a full expiry period from now rather than expiring all at once.
+func migrateListingLifecycle() {
+	result := DB.Model(&models.Product{}).
+		Where("published_at IS NULL AND status IN ?", []string{"available", "requested", "sold"}).
+		UpdateColumn("published_at", gorm.Expr("created_at"))
+	if result.Error != nil {
+		log.Printf("Failed to backfill published_at: %v", result.Error)
+	}
+
+	expiresAt := time.Now().AddDate(0, 0, ListingExpiryDays())
+	result = DB.Model(&models.Product{}).
+		Where("expires_at IS NULL AND status = ?", "available").
+		UpdateColumn("expires_at", expiresAt)
+	if result.Error != nil {
+		log.Printf("Failed to backfill expires_at: %v", result.Error)
+	} else if result.RowsAffected > 0 {
+		log.Printf("Live listings without an expiry now expire on %s: %d", expiresAt.Format("2006-01-02"), result.RowsAffected)
+	}
+
+	result = DB.Model(&models.Product{}).
+		Where("sold_at IS NULL AND status = ?", "sold").
+		UpdateColumn("sold_at", gorm.Expr("updated_at"))
+	if result.Error != nil {
+		log.Printf("Failed to backfill sold_at: %v", result.Error)
+	}
+}
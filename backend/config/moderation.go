@@ -0,0 +1,7 @@
+package config
+
+// ReportAutoHideThreshold is how many distinct users have to report a product, user or message before
+// it is hidden pending moderation (REPORT_AUTO_HIDE_THRESHOLD, default 3)
+func ReportAutoHideThreshold() int {
+	return envInt("REPORT_AUTO_HIDE_THRESHOLD", 3)
+}
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"encoding/json"
+	"log"
+	"strings"
+
+	"marketplace-backend/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	MaxTagsPerProduct = 10
+	MaxTagLength      = 32
+)
+
+// ParseTags accepts tags as a JSON array (`["a","b"]`) or comma-separated text (`a, b`)
+// and returns them normalized
+func ParseTags(raw string) []string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return []string{}
+	}
+
+	var tags []string
+	if strings.HasPrefix(raw, "[") && json.Unmarshal([]byte(raw), &tags) == nil {
+		return NormalizeTags(tags)
+	}
+	return NormalizeTags(strings.Split(raw, ","))
+}
+
+// NormalizeTags lowercases, trims and dedupes tags, dropping empty ones and keeping the first MaxTagsPerProduct
+func NormalizeTags(tags []string) []string {
+	normalized := []string{}
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		tag = strings.Join(strings.Fields(strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))), " ")
+		if len(tag) > MaxTagLength {
+			tag = strings.TrimSpace(tag[:MaxTagLength])
+		}
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+		if len(normalized) == MaxTagsPerProduct {
+			break
+		}
+	}
+	return normalized
+}
+
+// SetProductTags replaces a product's tags, creating missing tags and keeping
+// the products.tags column in sync as a JSON array
+func SetProductTags(db *gorm.DB, product *models.Product, names []string) error {
+	names = NormalizeTags(names)
+
+	tags := []models.Tag{}
+	if len(names) > 0 {
+		newTags := make([]models.Tag, 0, len(names))
+		for _, name := range names {
+			newTags = append(newTags, models.Tag{Name: name})
+		}
+		if err := db.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "name"}}, DoNothing: true}).Create(&newTags).Error; err != nil {
+			return err
+		}
+		if err := db.Where("name IN ?", names).Find(&tags).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := db.Model(product).Association("TagList").Replace(tags); err != nil {
+		return err
+	}
+
+	tagsJSON, _ := json.Marshal(names)
+	product.Tags = string(tagsJSON)
+	return db.Model(product).UpdateColumn("tags", product.Tags).Error
+}
+
+// migrateProductTags moves tags from the old free-form products.tags column into product_tags
+func migrateProductTags() {
+	var products []models.Product
+	err := DB.Select("id", "tags").
+		Where("tags IS NOT NULL AND tags <> '' AND tags <> '[]'").
+		Where("NOT EXISTS (SELECT 1 FROM product_tags WHERE product_tags.product_id = products.id)").
+		Find(&products).Error
+	if err != nil {
+		log.Printf("Failed to load products for tag migration: %v", err)
+		return
+	}
+
+	migrated := 0
+	for i := range products {
+		if err := SetProductTags(DB, &products[i], ParseTags(products[i].Tags)); err != nil {
+			log.Printf("Failed to migrate tags for product %s: %v", products[i].ID, err)
+			continue
+		}
+		migrated++
+	}
+	if migrated > 0 {
+		log.Printf("Migrated tags for %d products", migrated)
+	}
+}
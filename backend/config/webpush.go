@@ -0,0 +1,315 @@
+package config
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/hkdf"
+)
+
+// PushTarget identifies a browser push subscription
+type PushTarget struct {
+	Endpoint string
+	P256dh   string // base64url-encoded uncompressed P-256 public key of the browser
+	Auth     string // base64url-encoded 16-byte authentication secret
+}
+
+// PushSender delivers an encrypted Web Push message to one subscription
+type PushSender interface {
+	Send(target PushTarget, payload []byte, ttl time.Duration) error
+}
+
+// pushServiceHosts are the push services browsers hand out endpoints for; a host matches itself and its subdomains
+var pushServiceHosts = []string{
+	"fcm.googleapis.com",        // Chrome, Edge on Android, Opera
+	"android.googleapis.com",    // older Chrome subscriptions
+	"push.services.mozilla.com", // Firefox autopush
+	"push.apple.com",            // Safari
+	"notify.windows.com",        // Edge on Windows (WNS)
+}
+
+// fakePushEndpoints lets subscriptions point at the fake push service (WEBPUSH_FAKE_ENDPOINT=true), which
+// lives on this server and is usually plain http
+func fakePushEndpoints() bool {
+	return os.Getenv("WEBPUSH_FAKE_ENDPOINT") == "true"
+}
+
+// fakePushHosts are this server's own addresses (the API listens on port 8080); fake push endpoints must
+// use one of them so the server can't be pointed at other hosts
+var fakePushHosts = []string{"localhost:8080", "127.0.0.1:8080", "[::1]:8080"}
+
+func isFakePushEndpoint(u *url.URL) bool {
+	if !fakePushEndpoints() || (u.Scheme != "http" && u.Scheme != "https") || !strings.HasPrefix(u.Path, "/api/dev/push/") {
+		return false
+	}
+	for _, host := range fakePushHosts {
+		if strings.EqualFold(u.Host, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidatePushEndpoint checks that a subscription endpoint is an https URL on a known push service,
+// so the server never POSTs to an address a client made up
+func ValidatePushEndpoint(endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err == nil && isFakePushEndpoint(u) {
+		return nil
+	}
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" {
+		return fmt.Errorf("push endpoint must be an https URL")
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, allowed := range pushServiceHosts {
+		if host == allowed || strings.HasSuffix(host, "."+allowed) {
+			return nil
+		}
+	}
+	return fmt.Errorf("push endpoint %s is not a known push service", host)
+}
+
+// ErrPushSubscriptionGone is returned when the push service reports the subscription expired (404/410)
+var ErrPushSubscriptionGone = errors.New("push subscription is no longer valid")
+
+// Pusher is the configured Web Push sender, nil when push is disabled
+var Pusher PushSender
+
+// ConnectWebPush loads the VAPID key pair from VAPID_PUBLIC_KEY / VAPID_PRIVATE_KEY
+func ConnectWebPush() {
+	publicKey := os.Getenv("VAPID_PUBLIC_KEY")
+	privateKey := os.Getenv("VAPID_PRIVATE_KEY")
+	subject := os.Getenv("VAPID_SUBJECT")
+
+	if publicKey == "" || privateKey == "" {
+		fmt.Println("VAPID keys not found. Web Push notifications disabled.")
+		return
+	}
+	if subject == "" {
+		subject = "mailto:admin@marketplace.local"
+	}
+
+	sender, err := NewVAPIDSender(publicKey, privateKey, subject)
+	if err != nil {
+		panic(fmt.Sprintf("Invalid VAPID configuration: %v", err))
+	}
+
+	Pusher = sender
+	fmt.Println("Web Push notifications enabled")
+}
+
+// GetVAPIDPublicKey returns the application server key browsers subscribe with
+func GetVAPIDPublicKey() string {
+	return os.Getenv("VAPID_PUBLIC_KEY")
+}
+
+// VAPIDSender sends RFC 8291 encrypted messages authenticated with RFC 8292 VAPID
+type VAPIDSender struct {
+	publicKey  string
+	privateKey *ecdsa.PrivateKey
+	subject    string
+	client     *http.Client
+}
+
+// NewVAPIDSender builds a sender from base64url-encoded keys
+func NewVAPIDSender(publicKey, privateKey, subject string) (*VAPIDSender, error) {
+	d, err := decodeBase64URL(privateKey)
+	if err != nil || len(d) != 32 {
+		return nil, fmt.Errorf("VAPID private key must be a base64url-encoded 32-byte scalar")
+	}
+
+	ecdhKey, err := ecdh.P256().NewPrivateKey(d)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %v", err)
+	}
+	pub := ecdhKey.PublicKey().Bytes()
+
+	expected, err := decodeBase64URL(publicKey)
+	if err != nil || !bytes.Equal(expected, pub) {
+		return nil, fmt.Errorf("VAPID public key does not match the private key")
+	}
+
+	key := &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(pub[1:33]),
+			Y:     new(big.Int).SetBytes(pub[33:65]),
+		},
+		D: new(big.Int).SetBytes(d),
+	}
+
+	// The fake push service is on this server's loopback address, which the public client refuses;
+	// endpoints are still limited to fakePushHosts and redirects are not followed
+	client := NewPublicHTTPClient(10 * time.Second)
+	if fakePushEndpoints() {
+		client = &http.Client{
+			Timeout: 10 * time.Second,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+	}
+
+	return &VAPIDSender{
+		publicKey:  publicKey,
+		privateKey: key,
+		subject:    subject,
+		client:     client,
+	}, nil
+}
+
+func (s *VAPIDSender) Send(target PushTarget, payload []byte, ttl time.Duration) error {
+	body, err := encryptPushPayload(target, payload)
+	if err != nil {
+		return err
+	}
+
+	if err := ValidatePushEndpoint(target.Endpoint); err != nil {
+		return err
+	}
+	endpoint, err := url.Parse(target.Endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid push endpoint: %v", err)
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"aud": endpoint.Scheme + "://" + endpoint.Host,
+		"exp": time.Now().Add(12 * time.Hour).Unix(),
+		"sub": s.subject,
+	})
+	signed, err := token.SignedString(s.privateKey)
+	if err != nil {
+		return fmt.Errorf("failed to sign VAPID token: %v", err)
+	}
+
+	req, err := http.NewRequest("POST", target.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("vapid t=%s, k=%s", signed, s.publicKey))
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("TTL", strconv.Itoa(int(ttl.Seconds())))
+	req.Header.Set("Urgency", "normal")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("push request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return ErrPushSubscriptionGone
+	case resp.StatusCode >= 300:
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("push service returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// encryptPushPayload encrypts a single-record aes128gcm message for the subscription (RFC 8291)
+func encryptPushPayload(target PushTarget, payload []byte) ([]byte, error) {
+	uaPublicBytes, err := decodeBase64URL(target.P256dh)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh key: %v", err)
+	}
+	authSecret, err := decodeBase64URL(target.Auth)
+	if err != nil || len(authSecret) != 16 {
+		return nil, fmt.Errorf("invalid auth secret")
+	}
+
+	uaPublic, err := ecdh.P256().NewPublicKey(uaPublicBytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh key: %v", err)
+	}
+	asPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	return encryptPushRecord(uaPublic, authSecret, asPrivate, salt, payload)
+}
+
+// encryptPushRecord does the encryption with a given ephemeral key and salt
+func encryptPushRecord(uaPublic *ecdh.PublicKey, authSecret []byte, asPrivate *ecdh.PrivateKey, salt, payload []byte) ([]byte, error) {
+	uaPublicBytes := uaPublic.Bytes()
+	asPublic := asPrivate.PublicKey().Bytes()
+	ecdhSecret, err := asPrivate.ECDH(uaPublic)
+	if err != nil {
+		return nil, err
+	}
+
+	keyInfo := append([]byte("WebPush: info\x00"), uaPublicBytes...)
+	keyInfo = append(keyInfo, asPublic...)
+	ikm, err := hkdfBytes(ecdhSecret, authSecret, keyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+	cek, err := hkdfBytes(ikm, salt, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hkdfBytes(ikm, salt, []byte("Content-Encoding: nonce\x00"), 12)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// 0x02 marks the last (and only) record
+	plaintext := append(append([]byte{}, payload...), 0x02)
+	ciphertext := gcm.Seal(nil, nonce, plaintext, nil)
+
+	const recordSize = 4096
+	header := make([]byte, 0, 16+4+1+len(asPublic))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, recordSize)
+	header = append(header, byte(len(asPublic)))
+	header = append(header, asPublic...)
+
+	return append(header, ciphertext...), nil
+}
+
+func hkdfBytes(secret, salt, info []byte, length int) ([]byte, error) {
+	out := make([]byte, length)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, secret, salt, info), out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// decodeBase64URL accepts base64url with or without padding
+func decodeBase64URL(value string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+}
@@ -0,0 +1,178 @@
+package config
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+func TestValidatePushEndpoint(t *testing.T) {
+	t.Setenv("WEBPUSH_FAKE_ENDPOINT", "")
+
+	tests := []struct {
+		endpoint string
+		valid    bool
+	}{
+		{"https://fcm.googleapis.com/fcm/send/abc", true},
+		{"https://updates.push.services.mozilla.com/wpush/v2/abc", true},
+		{"https://web.push.apple.com/abc", true},
+		{"https://db5p.notify.windows.com/w/?token=abc", true},
+		{"http://fcm.googleapis.com/fcm/send/abc", false},
+		{"https://evilfcm.googleapis.com.example.com/abc", false},
+		{"https://169.254.169.254/latest/meta-data", false},
+		{"https://localhost/api/dev/push/abc", false},
+		{"not a url", false},
+	}
+	for _, tt := range tests {
+		if err := ValidatePushEndpoint(tt.endpoint); (err == nil) != tt.valid {
+			t.Errorf("ValidatePushEndpoint(%q) = %v, want valid %v", tt.endpoint, err, tt.valid)
+		}
+	}
+}
+
+func TestValidatePushEndpointFakeService(t *testing.T) {
+	t.Setenv("WEBPUSH_FAKE_ENDPOINT", "true")
+
+	tests := []struct {
+		endpoint string
+		valid    bool
+	}{
+		{"http://localhost:8080/api/dev/push/abc", true},
+		{"http://127.0.0.1:8080/api/dev/push/abc", true},
+		{"http://[::1]:8080/api/dev/push/abc", true},
+		{"https://fcm.googleapis.com/fcm/send/abc", true},
+		{"http://localhost:8080/api/products", false},
+		{"http://10.0.0.5:8080/api/dev/push/abc", false},
+		{"http://internal.example.com/api/dev/push/abc", false},
+		{"http://localhost:6379/api/dev/push/abc", false},
+		{"http://localhost/api/dev/push/abc", false},
+		{"ftp://localhost:8080/api/dev/push/abc", false},
+	}
+	for _, tt := range tests {
+		if err := ValidatePushEndpoint(tt.endpoint); (err == nil) != tt.valid {
+			t.Errorf("ValidatePushEndpoint(%q) = %v, want valid %v", tt.endpoint, err, tt.valid)
+		}
+	}
+}
+
+// RFC 8291 appendix A
+const (
+	rfc8291Plaintext     = "When I grow up, I want to be a watermelon"
+	rfc8291ASPrivate     = "yfWPiYE-n46HLnH0KqZOF1fJJU3MYrct3AELtAQ-oRw"
+	rfc8291UAPrivate     = "q1dXpw3UpT5VOmu_cf_v6ih07Aems3njxI-JWgLcM94"
+	rfc8291UAPublic      = "BCVxsr7N_eNgVRqvHtD0zTZsEc6-VV-JvLexhqUzORcxaOzi6-AYWXvTBHm4bjyPjs7Vd8pZGH6SRpkNtoIAiw4"
+	rfc8291Salt          = "DGv6ra1nlYgDCS1FRnbzlw"
+	rfc8291AuthSecret    = "BTBZMqHH6r4Tts7J_aSIgg"
+	rfc8291EncodedRecord = "DGv6ra1nlYgDCS1FRnbzlwAAEABBBP4z9KsN6nGRTbVYI_c7VJSPQTBtkgcy27mlmlMoZIIgDll6e3vCYLocInmYWAmS6TlzAC8wEqKK6PBru3jl7A_yl95bQpu6cVPTpK4Mqgkf1CXztLVBSt2Ks3oZwbuwXPXLWyouBWLVWGNWQexSgSxsj_Qulcy4a-fN"
+)
+
+func TestEncryptPushRecordRFC8291(t *testing.T) {
+	uaPublic, err := ecdh.P256().NewPublicKey(mustDecode(t, rfc8291UAPublic))
+	if err != nil {
+		t.Fatal(err)
+	}
+	asPrivate, err := ecdh.P256().NewPrivateKey(mustDecode(t, rfc8291ASPrivate))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := encryptPushRecord(uaPublic, mustDecode(t, rfc8291AuthSecret), asPrivate, mustDecode(t, rfc8291Salt), []byte(rfc8291Plaintext))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if encoded := base64.RawURLEncoding.EncodeToString(got); encoded != rfc8291EncodedRecord {
+		t.Errorf("encrypted record = %s, want %s", encoded, rfc8291EncodedRecord)
+	}
+}
+
+func TestEncryptPushPayload(t *testing.T) {
+	uaPrivate, err := ecdh.P256().NewPrivateKey(mustDecode(t, rfc8291UAPrivate))
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid := PushTarget{P256dh: rfc8291UAPublic, Auth: rfc8291AuthSecret}
+
+	tests := []struct {
+		name    string
+		target  PushTarget
+		payload string
+		wantErr bool
+	}{
+		{"valid", valid, rfc8291Plaintext, false},
+		{"padded base64", PushTarget{P256dh: rfc8291UAPublic, Auth: rfc8291AuthSecret + "=="}, "{}", false},
+		{"empty payload", valid, "", false},
+		{"p256dh not base64", PushTarget{P256dh: "not base64!", Auth: rfc8291AuthSecret}, "{}", true},
+		{"p256dh not a curve point", PushTarget{P256dh: rfc8291Salt, Auth: rfc8291AuthSecret}, "{}", true},
+		{"short auth secret", PushTarget{P256dh: rfc8291UAPublic, Auth: "BTBZMqHH6r4"}, "{}", true},
+	}
+	for _, tt := range tests {
+		body, err := encryptPushPayload(tt.target, []byte(tt.payload))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: err = %v, want error %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		plaintext, err := decryptPushRecord(uaPrivate, mustDecode(t, rfc8291AuthSecret), body)
+		if err != nil {
+			t.Errorf("%s: decrypting: %v", tt.name, err)
+		} else if string(plaintext) != tt.payload {
+			t.Errorf("%s: decrypted %q, want %q", tt.name, plaintext, tt.payload)
+		}
+	}
+}
+
+// decryptPushRecord is the user agent side of RFC 8291, for checking what encryptPushPayload produces
+func decryptPushRecord(uaPrivate *ecdh.PrivateKey, authSecret, body []byte) ([]byte, error) {
+	salt, rs, idlen := body[:16], binary.BigEndian.Uint32(body[16:20]), int(body[20])
+	asPublicBytes, ciphertext := body[21:21+idlen], body[21+idlen:]
+	if rs != 4096 {
+		return nil, errors.New("unexpected record size")
+	}
+
+	asPublic, err := ecdh.P256().NewPublicKey(asPublicBytes)
+	if err != nil {
+		return nil, err
+	}
+	ecdhSecret, err := uaPrivate.ECDH(asPublic)
+	if err != nil {
+		return nil, err
+	}
+	keyInfo := append([]byte("WebPush: info\x00"), uaPrivate.PublicKey().Bytes()...)
+	keyInfo = append(keyInfo, asPublicBytes...)
+	ikm, _ := hkdfBytes(ecdhSecret, authSecret, keyInfo, 32)
+	cek, _ := hkdfBytes(ikm, salt, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	nonce, _ := hkdfBytes(ikm, salt, []byte("Content-Encoding: nonce\x00"), 12)
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasSuffix(plaintext, []byte{0x02}) {
+		return nil, errors.New("missing last-record delimiter")
+	}
+	return plaintext[:len(plaintext)-1], nil
+}
+
+func mustDecode(t *testing.T, value string) []byte {
+	t.Helper()
+	b, err := decodeBase64URL(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
@@ -0,0 +1,301 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"marketplace-backend/config"
+	"marketplace-backend/middleware"
+	"marketplace-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// DeletedUserName replaces the name of a deleted account wherever it still appears, e.g. in chats
+const DeletedUserName = "Deleted user"
+
+// DeleteAccountRequest re-authenticates the caller before their account is deleted
+type DeleteAccountRequest struct {
+	Password string `json:"password"`
+	// Email confirms the deletion for accounts without a password
+	Email string `json:"email"`
+}
+
+// DeleteMyAccount anonymizes and soft-deletes the caller's account: listings are withdrawn, open
+// purchase requests declined, personal data and uploaded images removed. Chats stay readable for the
+// other party with the account shown as "Deleted user"; purgeDeletedAccounts finishes the job later.
+func DeleteMyAccount(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var user models.User
+	if err := config.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if user.Password != "" {
+		if req.Password == "" || bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)) != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Enter your password to delete your account"})
+			return
+		}
+	} else if !strings.EqualFold(strings.TrimSpace(req.Email), user.Email) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Enter your email address to delete your account"})
+		return
+	}
+
+	var declined []models.PurchaseRequest
+	var blobs []string
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if declined, blobs, err = deleteAccount(tx, &user); err != nil {
+			return err
+		}
+		return recordAudit(tx, c, AuditEntry{Action: AuditAccountDeleted, TargetType: "user", TargetID: user.ID})
+	})
+	if err != nil {
+		log.Printf("Failed to delete account %s: %v", user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete account"})
+		return
+	}
+	middleware.InvalidateAccountStatus(user.ID)
+
+	// Storage is cleaned up after the commit; a failure here only leaves orphaned files behind
+	for _, blob := range blobs {
+		container, name, _ := strings.Cut(blob, "/")
+		if err := config.DeleteBlob(container, name); err != nil {
+			log.Printf("Failed to delete %s of deleted account %s: %v", blob, user.ID, err)
+		}
+	}
+
+	for _, request := range declined {
+		notify(NotificationEvent{
+			UserID: request.BuyerID,
+			Type:   NotificationEventPurchaseRequestDeclined,
+			Title:  "Purchase request declined",
+			Body:   "The seller closed their account, so this listing is no longer available",
+			Link:   "/chats",
+		})
+	}
+
+	log.Printf("Account %s deleted", user.ID)
+	c.JSON(http.StatusOK, gin.H{"message": "Your account has been deleted"})
+}
+
+// deleteAccount does the database side of an account deletion and returns the requests declined on
+// the user's listings and the blobs ("container/name") to remove once the transaction has committed
+func deleteAccount(tx *gorm.DB, user *models.User) ([]models.PurchaseRequest, []string, error) {
+	var blobs []string
+
+	// Withdraw listings, declining requests on them and marking their chats
+	var products []models.Product
+	if err := tx.Unscoped().Where("seller_id = ?", user.ID).Find(&products).Error; err != nil {
+		return nil, nil, err
+	}
+	var declined []models.PurchaseRequest
+	for i := range products {
+		product := &products[i]
+		blobs = append(blobs, productImageBlobs(product)...)
+		if err := tx.Unscoped().Model(product).UpdateColumn("images", "[]").Error; err != nil {
+			return nil, nil, err
+		}
+		if product.DeletedAt.Valid {
+			continue
+		}
+		requests, err := removeListing(tx, product, user.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		declined = append(declined, requests...)
+	}
+
+	// Withdraw the user's own open requests
+	err := tx.Model(&models.PurchaseRequest{}).
+		Where("buyer_id = ? AND status = ?", user.ID, "pending").
+		Update("status", "declined").Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var chatIDs []uuid.UUID
+	if err := tx.Table("chat_participants").Where("user_id = ?", user.ID).Pluck("chat_id", &chatIDs).Error; err != nil {
+		return nil, nil, err
+	}
+	for _, chatID := range chatIDs {
+		if err := postSystemMessage(tx, chatID, user.ID, "This account has been deleted."); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Personal data nobody else needs
+	personal := []interface{}{
+		&models.Favorite{}, &models.WishlistCollection{}, &models.SavedSearch{}, &models.PushSubscription{},
+		&models.Notification{}, &models.NotificationPreference{}, &models.DigestCursor{}, &models.ProductImport{},
+	}
+	for _, model := range personal {
+		if err := tx.Where("user_id = ?", user.ID).Delete(model).Error; err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := tx.Where("user_id = ? AND status = ?", user.ID, "pending").Delete(&models.EmailJob{}).Error; err != nil {
+		return nil, nil, err
+	}
+	if err := tx.Where("viewer_key = ?", user.ID.String()).Delete(&models.ProductView{}).Error; err != nil {
+		return nil, nil, err
+	}
+	if err := tx.Where("follower_id = ? OR seller_id = ?", user.ID, user.ID).Delete(&models.Follow{}).Error; err != nil {
+		return nil, nil, err
+	}
+	if err := tx.Where("blocker_id = ? OR blocked_id = ?", user.ID, user.ID).Delete(&models.Block{}).Error; err != nil {
+		return nil, nil, err
+	}
+
+	var exports []models.DataExport
+	if err := tx.Where("user_id = ? AND blob_name <> ''", user.ID).Find(&exports).Error; err != nil {
+		return nil, nil, err
+	}
+	for _, export := range exports {
+		blobs = append(blobs, config.ExportsContainer+"/"+export.BlobName)
+	}
+	if err := tx.Where("user_id = ?", user.ID).Delete(&models.DataExport{}).Error; err != nil {
+		return nil, nil, err
+	}
+
+	if name := imageBlobName(user.Avatar); name != "" {
+		blobs = append(blobs, "images/"+name)
+	}
+
+	// Anonymize, freeing the email address for a new account, then soft-delete
+	err = tx.Model(user).Updates(map[string]interface{}{
+		"name":       DeletedUserName,
+		"email":      fmt.Sprintf("deleted-%s@deleted.invalid", user.ID),
+		"password":   "",
+		"avatar":     "",
+		"year":       "",
+		"department": "",
+		"is_admin":   false,
+	}).Error
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := tx.Delete(user).Error; err != nil {
+		return nil, nil, err
+	}
+
+	return declined, blobs, nil
+}
+
+// productImageBlobs returns the stored images of a product as "container/name"
+func productImageBlobs(product *models.Product) []string {
+	var images []string
+	json.Unmarshal([]byte(product.Images), &images)
+
+	blobs := make([]string, 0, len(images))
+	for _, image := range images {
+		if name := imageBlobName(image); name != "" {
+			blobs = append(blobs, "images/"+name)
+		}
+	}
+	return blobs
+}
+
+// imageBlobName returns the blob name of an image URL in our images container, or "" for other URLs
+func imageBlobName(url string) string {
+	prefix := config.GetBlobContainerURL() + "/images/"
+	if !strings.HasPrefix(url, prefix) {
+		return ""
+	}
+	return strings.TrimPrefix(url, prefix)
+}
+
+// purgeDeletedAccounts removes what is left of accounts deleted more than ACCOUNT_PURGE_DAYS ago:
+// message texts, and listings nobody else has a chat about. The anonymized user row is dropped
+// too when nothing references it any more; otherwise it stays as the "Deleted user" tombstone.
+func purgeDeletedAccounts() {
+	cutoff := time.Now().AddDate(0, 0, -config.AccountPurgeDays())
+	var users []models.User
+	if err := config.DB.Unscoped().Where("deleted_at <= ? AND purged_at IS NULL", cutoff).Find(&users).Error; err != nil {
+		log.Printf("Failed to load deleted accounts: %v", err)
+		return
+	}
+
+	for i := range users {
+		user := &users[i]
+		if err := config.DB.Transaction(func(tx *gorm.DB) error { return purgeAccount(tx, user) }); err != nil {
+			log.Printf("Failed to purge deleted account %s: %v", user.ID, err)
+			continue
+		}
+		log.Printf("Purged deleted account %s", user.ID)
+	}
+}
+
+func purgeAccount(tx *gorm.DB, user *models.User) error {
+	err := tx.Model(&models.Message{}).
+		Where("from_id = ? AND is_system = ?", user.ID, false).
+		Update("text", "This message was deleted").Error
+	if err != nil {
+		return err
+	}
+
+	// Listings without chats are only theirs and can go entirely
+	var productIDs []uuid.UUID
+	err = tx.Unscoped().Model(&models.Product{}).
+		Where("seller_id = ? AND NOT EXISTS (SELECT 1 FROM chats WHERE chats.product_id = products.id)", user.ID).
+		Pluck("id", &productIDs).Error
+	if err != nil {
+		return err
+	}
+	if len(productIDs) > 0 {
+		for _, model := range []interface{}{&models.Favorite{}, &models.CollectionItem{}, &models.ProductPriceHistory{}, &models.ProductView{}} {
+			if err := tx.Where("product_id IN ?", productIDs).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Exec("DELETE FROM product_tags WHERE product_id IN ?", productIDs).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("id IN ?", productIDs).Delete(&models.Product{}).Error; err != nil {
+			return err
+		}
+	}
+
+	// Notifications may have been recorded for the account after it was deleted
+	if err := tx.Where("user_id = ?", user.ID).Delete(&models.Notification{}).Error; err != nil {
+		return err
+	}
+
+	var references int64
+	for _, query := range []*gorm.DB{
+		tx.Unscoped().Model(&models.Product{}).Where("seller_id = ?", user.ID),
+		tx.Model(&models.PurchaseRequest{}).Where("buyer_id = ? OR seller_id = ?", user.ID, user.ID),
+		tx.Model(&models.Message{}).Where("from_id = ?", user.ID),
+		tx.Table("chat_participants").Where("user_id = ?", user.ID),
+		tx.Model(&models.Meetup{}).Where("proposed_by_id = ?", user.ID),
+	} {
+		var count int64
+		if err := query.Count(&count).Error; err != nil {
+			return err
+		}
+		references += count
+	}
+	if references == 0 {
+		return tx.Unscoped().Delete(user).Error
+	}
+	return tx.Unscoped().Model(user).UpdateColumn("purged_at", time.Now()).Error
+}
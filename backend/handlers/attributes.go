@@ -0,0 +1,308 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"marketplace-backend/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	AttributeTypeString  = "string"
+	AttributeTypeInteger = "integer"
+	AttributeTypeNumber  = "number"
+	AttributeTypeBoolean = "boolean"
+	AttributeTypeEnum    = "enum"
+
+	maxAttributeStringLength = 200
+	attributeFilterPrefix    = "attr."
+)
+
+// productAttributesSQL is products.attributes as jsonb; older rows may hold an empty string
+const productAttributesSQL = "COALESCE(NULLIF(products.attributes, ''), '{}')::jsonb"
+
+// parseProductAttributes reads the JSON object of listing attributes sent by the client
+func parseProductAttributes(raw string) (map[string]interface{}, error) {
+	attributes := map[string]interface{}{}
+	if strings.TrimSpace(raw) == "" {
+		return attributes, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &attributes); err != nil {
+		return nil, fmt.Errorf("attributes must be a JSON object")
+	}
+	return attributes, nil
+}
+
+// validateProductAttributes checks listing attributes against a category schema and returns
+// them keyed by their normalized names and coerced to the declared types
+func validateProductAttributes(schema []models.AttributeDefinition, attributes map[string]interface{}) (map[string]interface{}, error) {
+	defs := make(map[string]models.AttributeDefinition, len(schema))
+	for _, def := range schema {
+		defs[def.Key] = def
+	}
+
+	validated := map[string]interface{}{}
+	for rawKey, value := range attributes {
+		key := normalizeAttributeKey(rawKey)
+		def, ok := defs[key]
+		if !ok {
+			return nil, fmt.Errorf("unknown attribute %q", rawKey)
+		}
+		if value == nil {
+			continue
+		}
+		if s, isString := value.(string); isString && strings.TrimSpace(s) == "" {
+			continue
+		}
+
+		coerced, err := coerceAttribute(def, value)
+		if err != nil {
+			return nil, err
+		}
+		validated[key] = coerced
+	}
+
+	var missing []string
+	for _, def := range schema {
+		if _, ok := validated[def.Key]; def.Required && !ok {
+			missing = append(missing, def.Key)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing required attributes: %s", strings.Join(missing, ", "))
+	}
+
+	return validated, nil
+}
+
+// coerceAttribute converts a value to the attribute's type; numbers and booleans may also
+// arrive as strings since listings are created from multipart forms
+func coerceAttribute(def models.AttributeDefinition, value interface{}) (interface{}, error) {
+	switch def.Type {
+	case AttributeTypeString:
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s must be text", def.Key)
+		}
+		s = strings.TrimSpace(s)
+		if len(s) > maxAttributeStringLength {
+			return nil, fmt.Errorf("%s must be at most %d characters", def.Key, maxAttributeStringLength)
+		}
+		return s, nil
+
+	case AttributeTypeInteger, AttributeTypeNumber:
+		var n float64
+		switch v := value.(type) {
+		case float64:
+			n = v
+		case string:
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s must be a number", def.Key)
+			}
+			n = parsed
+		default:
+			return nil, fmt.Errorf("%s must be a number", def.Key)
+		}
+		if math.IsNaN(n) || math.IsInf(n, 0) {
+			return nil, fmt.Errorf("%s must be a number", def.Key)
+		}
+		if def.Type == AttributeTypeInteger && n != math.Trunc(n) {
+			return nil, fmt.Errorf("%s must be a whole number", def.Key)
+		}
+		if def.Min != nil && n < *def.Min {
+			return nil, fmt.Errorf("%s must be at least %v", def.Key, *def.Min)
+		}
+		if def.Max != nil && n > *def.Max {
+			return nil, fmt.Errorf("%s must be at most %v", def.Key, *def.Max)
+		}
+		return n, nil
+
+	case AttributeTypeBoolean:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			if b, err := strconv.ParseBool(strings.TrimSpace(v)); err == nil {
+				return b, nil
+			}
+		}
+		return nil, fmt.Errorf("%s must be true or false", def.Key)
+
+	case AttributeTypeEnum:
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s must be one of %s", def.Key, strings.Join(def.Options, ", "))
+		}
+		for _, option := range def.Options {
+			if strings.EqualFold(option, strings.TrimSpace(s)) {
+				return option, nil
+			}
+		}
+		return nil, fmt.Errorf("%s must be one of %s", def.Key, strings.Join(def.Options, ", "))
+	}
+
+	return nil, fmt.Errorf("%s has an unsupported type %q", def.Key, def.Type)
+}
+
+// normalizeAttributeSchema validates an admin-supplied attribute schema
+func normalizeAttributeSchema(defs []models.AttributeDefinition) ([]models.AttributeDefinition, error) {
+	schema := make([]models.AttributeDefinition, 0, len(defs))
+	seen := map[string]bool{}
+	for _, def := range defs {
+		def.Key = normalizeAttributeKey(def.Key)
+		if def.Key == "" {
+			return nil, fmt.Errorf("attribute keys must contain letters or digits")
+		}
+		if seen[def.Key] {
+			return nil, fmt.Errorf("attribute %q is defined twice", def.Key)
+		}
+		seen[def.Key] = true
+
+		def.Label = strings.TrimSpace(def.Label)
+		if def.Label == "" {
+			def.Label = def.Key
+		}
+
+		switch def.Type {
+		case AttributeTypeString, AttributeTypeBoolean:
+			def.Options, def.Min, def.Max = nil, nil, nil
+		case AttributeTypeInteger, AttributeTypeNumber:
+			def.Options = nil
+			if def.Min != nil && def.Max != nil && *def.Min > *def.Max {
+				return nil, fmt.Errorf("attribute %q has min greater than max", def.Key)
+			}
+		case AttributeTypeEnum:
+			def.Min, def.Max = nil, nil
+			if len(def.Options) == 0 {
+				return nil, fmt.Errorf("enum attribute %q needs options", def.Key)
+			}
+		default:
+			return nil, fmt.Errorf("attribute %q has unsupported type %q", def.Key, def.Type)
+		}
+
+		schema = append(schema, def)
+	}
+	return schema, nil
+}
+
+func decodeAttributeSchema(raw string) []models.AttributeDefinition {
+	var schema []models.AttributeDefinition
+	if raw != "" {
+		json.Unmarshal([]byte(raw), &schema)
+	}
+	return schema
+}
+
+// attributeFiltersFromQuery reads ?attr.brand=dell&attr.storage_gb.min=256&attr.storage_gb.max=1024
+func attributeFiltersFromQuery(query url.Values) map[string]string {
+	filters := map[string]string{}
+	for param, values := range query {
+		if !strings.HasPrefix(param, attributeFilterPrefix) || len(values) == 0 || strings.TrimSpace(values[0]) == "" {
+			continue
+		}
+		key, bound := splitAttributeFilter(strings.TrimPrefix(param, attributeFilterPrefix))
+		if key == "" {
+			continue
+		}
+		if bound != "" {
+			key += "." + bound
+		}
+		filters[key] = strings.TrimSpace(values[0])
+	}
+	if len(filters) == 0 {
+		return nil
+	}
+	return filters
+}
+
+// splitAttributeFilter splits "storage_gb.min" into the attribute key and its bound ("min", "max" or "")
+func splitAttributeFilter(filter string) (string, string) {
+	key, bound := filter, ""
+	if i := strings.LastIndex(filter, "."); i >= 0 && (filter[i+1:] == "min" || filter[i+1:] == "max") {
+		key, bound = filter[:i], filter[i+1:]
+	}
+	return normalizeAttributeKey(key), bound
+}
+
+// applyAttributeFilters adds attribute conditions to a products query; ranges only match numeric values
+func applyAttributeFilters(db *gorm.DB, filters map[string]string) *gorm.DB {
+	keys := make([]string, 0, len(filters))
+	for filter := range filters {
+		keys = append(keys, filter)
+	}
+	sort.Strings(keys)
+
+	for _, filter := range keys {
+		value := filters[filter]
+		key, bound := splitAttributeFilter(filter)
+		if bound == "" {
+			db = db.Where("LOWER("+productAttributesSQL+" ->> ?) = LOWER(?)", key, value)
+			continue
+		}
+
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			continue
+		}
+		op := ">="
+		if bound == "max" {
+			op = "<="
+		}
+		db = db.Where("(CASE WHEN jsonb_typeof("+productAttributesSQL+" -> ?) = 'number' THEN ("+productAttributesSQL+" ->> ?)::numeric END) "+op+" ?", key, key, n)
+	}
+	return db
+}
+
+// matchesAttributeFilters reports whether a product satisfies the filters, using the same rules as applyAttributeFilters
+func matchesAttributeFilters(product *models.Product, filters map[string]string) bool {
+	if len(filters) == 0 {
+		return true
+	}
+	attributes, _ := parseProductAttributes(product.Attributes)
+
+	for filter, value := range filters {
+		key, bound := splitAttributeFilter(filter)
+		actual, ok := attributes[key]
+		if !ok {
+			return false
+		}
+
+		if bound == "" {
+			if !strings.EqualFold(formatAttributeValue(actual), value) {
+				return false
+			}
+			continue
+		}
+
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			continue
+		}
+		number, isNumber := actual.(float64)
+		if !isNumber || (bound == "min" && number < n) || (bound == "max" && number > n) {
+			return false
+		}
+	}
+	return true
+}
+
+// formatAttributeValue renders a value the way postgres' ->> does, so both matchers agree
+func formatAttributeValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+
+	"marketplace-backend/config"
+	"marketplace-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Audited actions
+const (
+	AuditListingRemoved  = "listing.removed"
+	AuditListingRestored = "listing.restored"
+	AuditReportResolved  = "report.resolved"
+	AuditReportDismissed = "report.dismissed"
+	AuditUserUpdated     = "user.updated"
+	AuditUserSuspended   = "user.suspended"
+	AuditUserBanned      = "user.banned"
+	AuditUserReinstated  = "user.reinstated"
+	AuditPasswordChanged = "account.password_changed"
+	AuditEmailChanged    = "account.email_changed"
+	AuditAccountDeleted  = "account.deleted"
+)
+
+const (
+	defaultAuditLogPageSize = 50
+	maxAuditLogPageSize     = 200
+	auditRedacted           = "[redacted]"
+)
+
+// auditSecretFields are recorded as changed without their values: passwords, and email addresses since
+// audit entries can't be anonymized when the account is deleted
+var auditSecretFields = map[string]bool{"password": true, "email": true}
+
+// AuditEntry describes an action for the audit log. Before and After are structs or maps in their JSON
+// form; only the fields that differ between them are stored.
+type AuditEntry struct {
+	Action     string
+	TargetType string
+	TargetID   uuid.UUID
+	Before     interface{}
+	After      interface{}
+	Note       string
+}
+
+// AuditChange is a field's value before and after an audited action
+type AuditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditLogDTO is an audit log entry with its changes decoded
+type AuditLogDTO struct {
+	models.AuditLog
+	Changes map[string]AuditChange `json:"changes"`
+}
+
+// recordAudit appends an entry to the audit log, taking the actor, IP and user agent from the request.
+// Pass the transaction of the audited change so both are committed (or rolled back) together.
+func recordAudit(db *gorm.DB, c *gin.Context, entry AuditEntry) error {
+	changes, err := json.Marshal(auditChanges(entry.Before, entry.After))
+	if err != nil {
+		return err
+	}
+
+	record := models.AuditLog{
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		Changes:    string(changes),
+		Note:       entry.Note,
+		IP:         c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+	}
+	if userID, exists := c.Get("userID"); exists {
+		actorID := userID.(uuid.UUID)
+		record.ActorID = &actorID
+	}
+	if entry.TargetID != uuid.Nil {
+		record.TargetID = &entry.TargetID
+	}
+	return db.Create(&record).Error
+}
+
+// auditChanges diffs the JSON forms of before and after; secret fields only show that they changed
+func auditChanges(before, after interface{}) map[string]AuditChange {
+	beforeFields, afterFields := auditFields(before), auditFields(after)
+	changes := map[string]AuditChange{}
+	for key, value := range afterFields {
+		if previous, ok := beforeFields[key]; !ok || !reflect.DeepEqual(previous, value) {
+			changes[key] = AuditChange{Before: beforeFields[key], After: value}
+		}
+	}
+	for key, value := range beforeFields {
+		if _, ok := afterFields[key]; !ok {
+			changes[key] = AuditChange{Before: value}
+		}
+	}
+	for key := range changes {
+		if auditSecretFields[key] {
+			changes[key] = AuditChange{Before: auditRedacted, After: auditRedacted}
+		}
+	}
+	return changes
+}
+
+func auditFields(value interface{}) map[string]interface{} {
+	fields := map[string]interface{}{}
+	if value == nil {
+		return fields
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fields
+	}
+	json.Unmarshal(data, &fields)
+	return fields
+}
+
+// GetAuditLogs lets admins search the audit log, newest first
+// (?actor_id=&action=&target_type=&target_id=&from=&to=&limit=&offset=)
+func GetAuditLogs(c *gin.Context) {
+	query := config.DB.Model(&models.AuditLog{})
+	for _, param := range []string{"actor_id", "target_id"} {
+		if value := c.Query(param); value != "" {
+			id, err := uuid.Parse(value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + param})
+				return
+			}
+			query = query.Where(param+" = ?", id)
+		}
+	}
+	if action := c.Query("action"); action != "" {
+		query = query.Where("action = ?", action)
+	}
+	if targetType := c.Query("target_type"); targetType != "" {
+		query = query.Where("target_type = ?", targetType)
+	}
+	for param, condition := range map[string]string{"from": "created_at >= ?", "to": "created_at < ?"} {
+		if value := c.Query(param); value != "" {
+			at, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": param + " must be an RFC3339 timestamp"})
+				return
+			}
+			query = query.Where(condition, at)
+		}
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultAuditLogPageSize)))
+	if err != nil || limit < 1 || limit > maxAuditLogPageSize {
+		limit = defaultAuditLogPageSize
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch audit log"})
+		return
+	}
+
+	var logs []models.AuditLog
+	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&logs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch audit log"})
+		return
+	}
+
+	entries := make([]AuditLogDTO, 0, len(logs))
+	for _, record := range logs {
+		entry := AuditLogDTO{AuditLog: record, Changes: map[string]AuditChange{}}
+		json.Unmarshal([]byte(record.Changes), &entry.Changes)
+		entries = append(entries, entry)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"entries": entries,
+		"total":   total,
+	})
+}
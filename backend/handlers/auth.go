@@ -3,13 +3,16 @@ package handlers
 import (
 	"net/http"
 	"os"
+	"strings"
 	"time"
 	"marketplace-backend/config"
+	"marketplace-backend/middleware"
 	"marketplace-backend/models"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 type LoginRequest struct {
@@ -27,7 +30,7 @@ type RegisterRequest struct {
 
 type AuthResponse struct {
 	Token string      `json:"token"`
-	User  models.User `json:"user"`
+	User  SelfUserDTO `json:"user"`
 }
 
 // Register creates a new user account
@@ -86,7 +89,7 @@ func Register(c *gin.Context) {
 
 	c.JSON(http.StatusCreated, AuthResponse{
 		Token: token,
-		User:  user,
+		User:  SelfUserDTOFromModel(&user),
 	})
 }
 
@@ -117,6 +120,12 @@ func Login(c *gin.Context) {
 		return
 	}
 
+	// Suspended and banned users can't sign in
+	if status := middleware.AccountStatusOf(&user); status.Status != middleware.AccountStatusActive {
+		middleware.AbortWithAccountStatus(c, status)
+		return
+	}
+
 	// Generate JWT token
 	token, err := generateJWT(user.ID.String())
 	if err != nil {
@@ -129,7 +138,7 @@ func Login(c *gin.Context) {
 
 	c.JSON(http.StatusOK, AuthResponse{
 		Token: token,
-		User:  user,
+		User:  SelfUserDTOFromModel(&user),
 	})
 }
 
@@ -147,7 +156,139 @@ func GetMe(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, user)
+	c.JSON(http.StatusOK, SelfUserDTOFromModel(&user))
+}
+
+type ChangePasswordRequest struct {
+	// CurrentPassword is required once the account has a password
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password" binding:"required,min=6"`
+	// Email confirms the change for accounts without a password
+	Email string `json:"email"`
+}
+
+type ChangeEmailRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+// ChangePassword sets a new password after checking the current one; accounts without a password
+// confirm with their email address instead
+func ChangePassword(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var user models.User
+	if err := config.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if user.Password != "" {
+		if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.CurrentPassword)) != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Current password is incorrect"})
+			return
+		}
+	} else if !strings.EqualFold(strings.TrimSpace(req.Email), user.Email) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Enter your email address to set a password"})
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		return
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Update("password", string(hashedPassword)).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, c, AuditEntry{
+			Action:     AuditPasswordChanged,
+			TargetType: "user",
+			TargetID:   user.ID,
+			Before:     gin.H{"password": user.Password != ""},
+			After:      gin.H{"password": true},
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change password"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password changed"})
+}
+
+// ChangeEmail moves the account to a new email address after checking the password
+func ChangeEmail(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req ChangeEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var user models.User
+	if err := config.DB.Preload("College").First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if user.Password == "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Set a password before changing your email address"})
+		return
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)) != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Password is incorrect"})
+		return
+	}
+
+	email := strings.TrimSpace(req.Email)
+	if strings.EqualFold(email, user.Email) {
+		c.JSON(http.StatusOK, SelfUserDTOFromModel(&user))
+		return
+	}
+
+	var existingUser models.User
+	if err := config.DB.Unscoped().Where("LOWER(email) = LOWER(?)", email).First(&existingUser).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "User with this email already exists"})
+		return
+	}
+
+	previous := user.Email
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Update("email", email).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, c, AuditEntry{
+			Action:     AuditEmailChanged,
+			TargetType: "user",
+			TargetID:   user.ID,
+			Before:     gin.H{"email": previous},
+			After:      gin.H{"email": email},
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change email"})
+		return
+	}
+
+	c.JSON(http.StatusOK, SelfUserDTOFromModel(&user))
 }
 
 // generateJWT creates a JWT token for a user
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"log"
+	"net/http"
+
+	"marketplace-backend/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	maxAvatarSize = 10 << 20
+	// maxAvatarPixels keeps a small file that decodes to a huge image from exhausting memory
+	maxAvatarPixels = 25_000_000
+	// avatarSide is the width and height avatars are stored at
+	avatarSide = 256
+)
+
+// UploadUserAvatar replaces a user's avatar with an uploaded image (multipart field "avatar"). The image is
+// cropped to a square, scaled down to 256px and re-encoded as JPEG before it goes through content safety.
+func UploadUserAvatar(c *gin.Context) {
+	user, ok := loadEditableUser(c)
+	if !ok {
+		return
+	}
+
+	file, err := c.FormFile("avatar")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Upload an image in the avatar field"})
+		return
+	}
+	if file.Size > maxAvatarSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Avatars can be at most %d MB", maxAvatarSize>>20)})
+		return
+	}
+	data, err := readFormFile(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read the uploaded image"})
+		return
+	}
+
+	avatar, err := resizeAvatar(data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	url, err := uploadImageBytesWithSafety(uuid.New().String()+".jpg", avatar)
+	if err != nil {
+		log.Printf("Avatar rejected for user %s: %v", user.ID, err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Avatar was rejected",
+			"reason":  "Content does not meet our community guidelines",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	previous := user.Avatar
+	if err := config.DB.Model(user).Update("avatar", url).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update avatar"})
+		return
+	}
+	if name := imageBlobName(previous); name != "" {
+		if err := config.DeleteBlob("images", name); err != nil {
+			log.Printf("Failed to delete previous avatar of user %s: %v", user.ID, err)
+		}
+	}
+
+	config.DB.Preload("College").First(user, user.ID)
+	c.JSON(http.StatusOK, userDTOFor(c, user))
+}
+
+// resizeAvatar decodes a JPEG, PNG or GIF, crops it to a centered square and scales it down to avatarSide,
+// averaging the source pixels under each output pixel. Transparent areas become white.
+func resizeAvatar(data []byte) ([]byte, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("Avatar must be a JPEG, PNG or GIF image")
+	}
+	if cfg.Width*cfg.Height > maxAvatarPixels {
+		return nil, fmt.Errorf("Avatar image is too large, use at most %d megapixels", maxAvatarPixels/1_000_000)
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("Avatar image could not be read")
+	}
+
+	bounds := src.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+	if side == 0 {
+		return nil, fmt.Errorf("Avatar image is empty")
+	}
+	origin := image.Pt(bounds.Min.X+(bounds.Dx()-side)/2, bounds.Min.Y+(bounds.Dy()-side)/2)
+	size := min(side, avatarSide)
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		y0, y1 := y*side/size, (y+1)*side/size
+		for x := 0; x < size; x++ {
+			x0, x1 := x*side/size, (x+1)*side/size
+			var r, g, b, a uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := src.At(origin.X+sx, origin.Y+sy).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+				}
+			}
+			n := uint64((y1 - y0) * (x1 - x0))
+			// Colors are alpha-premultiplied, so compositing over white adds the missing coverage as white
+			white := 0xffff - a/n
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8((r/n + white) >> 8),
+				G: uint8((g/n + white) >> 8),
+				B: uint8((b/n + white) >> 8),
+				A: 0xff,
+			})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"marketplace-backend/config"
+	"marketplace-backend/middleware"
+	"marketplace-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// BlockUser blocks another user: neither can message or send purchase requests to the other, or see
+// the other's listings. Follows between the two are removed.
+func BlockUser(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	blockedID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+	if blockedID == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot block yourself"})
+		return
+	}
+
+	var blocked models.User
+	if err := config.DB.First(&blocked, blockedID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	var block models.Block
+	if config.DB.Where("blocker_id = ? AND blocked_id = ?", userID, blockedID).First(&block).Error == nil {
+		c.JSON(http.StatusOK, block)
+		return
+	}
+
+	block = models.Block{BlockerID: userID.(uuid.UUID), BlockedID: blockedID}
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&block).Error; err != nil {
+			return err
+		}
+		return tx.Where("(follower_id = ? AND seller_id = ?) OR (follower_id = ? AND seller_id = ?)",
+			userID, blockedID, blockedID, userID).Delete(&models.Follow{}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to block user"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, block)
+}
+
+// UnblockUser lifts a block the caller placed
+func UnblockUser(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	blockedID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	result := config.DB.Where("blocker_id = ? AND blocked_id = ?", userID, blockedID).Delete(&models.Block{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unblock user"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "You have not blocked this user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "User unblocked"})
+}
+
+// GetBlockedUsers returns the public profiles of the users the caller blocked
+func GetBlockedUsers(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var users []models.User
+	err := config.DB.Unscoped().Preload("College").
+		Joins("JOIN blocks ON blocks.blocked_id = users.id").
+		Where("blocks.blocker_id = ?", userID).
+		Order("blocks.created_at DESC").
+		Find(&users).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch blocked users"})
+		return
+	}
+
+	profiles := make([]PublicUserDTO, 0, len(users))
+	for i := range users {
+		profiles = append(profiles, PublicUserDTOFromModel(&users[i]))
+	}
+	c.JSON(http.StatusOK, profiles)
+}
+
+// usersBlocked reports whether either user blocked the other
+func usersBlocked(a, b uuid.UUID) bool {
+	var count int64
+	config.DB.Model(&models.Block{}).
+		Where("(blocker_id = ? AND blocked_id = ?) OR (blocker_id = ? AND blocked_id = ?)", a, b, b, a).
+		Count(&count)
+	return count > 0
+}
+
+// visibleListings is a scope for listing queries that leaves out listings hidden by moderation, those of
+// hidden, suspended or banned sellers and, for a signed-in caller, those of users they blocked or were
+// blocked by
+func visibleListings(c *gin.Context) func(db *gorm.DB) *gorm.DB {
+	viewerID, _ := c.Get("userID")
+	return visibleListingsTo(viewerID)
+}
+
+// visibleListingsTo is visibleListings outside a request, e.g. for emails; a nil viewer is anonymous
+func visibleListingsTo(viewerID interface{}) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Where("products.hidden_at IS NULL").
+			Where("products.seller_id NOT IN (SELECT id FROM users WHERE hidden_at IS NOT NULL OR banned_at IS NOT NULL OR suspended_until > ?)", time.Now())
+		if viewerID != nil {
+			db = db.Where("products.seller_id NOT IN (SELECT blocked_id FROM blocks WHERE blocker_id = ?)", viewerID).
+				Where("products.seller_id NOT IN (SELECT blocker_id FROM blocks WHERE blocked_id = ?)", viewerID)
+		}
+		return db
+	}
+}
+
+// sellerHidden reports whether a seller's listings and storefront are kept from other users: hidden by
+// moderation, suspended or banned
+func sellerHidden(seller *models.User) bool {
+	status := middleware.AccountStatusOf(seller).Status
+	return seller.HiddenAt != nil || status == middleware.AccountStatusSuspended || status == middleware.AccountStatusBanned
+}
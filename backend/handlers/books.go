@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"marketplace-backend/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ISBNLookupResponse is the normalized form of an ISBN plus any metadata found for it
+type ISBNLookupResponse struct {
+	ISBN13   string               `json:"isbn13"`
+	ISBN10   string               `json:"isbn10,omitempty"` // only for 978-prefixed ISBNs
+	Metadata *config.BookMetadata `json:"metadata"`
+}
+
+// LookupISBN validates an ISBN-10 or ISBN-13, normalizes it and fills in book metadata when known
+func LookupISBN(c *gin.Context) {
+	isbn13, err := normalizeISBN(c.Param("isbn"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := ISBNLookupResponse{ISBN13: isbn13, ISBN10: isbn13To10(isbn13)}
+	book, err := config.Books.LookupISBN(isbn13)
+	switch {
+	case err == nil:
+		response.Metadata = book
+	case !errors.Is(err, config.ErrBookNotFound):
+		log.Printf("Book metadata lookup failed for %s: %v", isbn13, err)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// normalizeISBN strips separators, checks the ISBN-10 or ISBN-13 checksum and returns the ISBN-13
+func normalizeISBN(raw string) (string, error) {
+	isbn := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(raw)))
+	if strings.HasPrefix(isbn, "ISBN") {
+		isbn = strings.TrimLeft(strings.TrimPrefix(isbn, "ISBN"), ":")
+	}
+
+	switch len(isbn) {
+	case 10:
+		sum := 0
+		for i, ch := range isbn {
+			var digit int
+			switch {
+			case ch >= '0' && ch <= '9':
+				digit = int(ch - '0')
+			case ch == 'X' && i == 9:
+				digit = 10
+			default:
+				return "", fmt.Errorf("ISBN may only contain digits (and a final X for ISBN-10)")
+			}
+			sum += digit * (10 - i)
+		}
+		if sum%11 != 0 {
+			return "", fmt.Errorf("invalid ISBN-10 checksum")
+		}
+		body := "978" + isbn[:9]
+		return body + isbn13CheckDigit(body), nil
+
+	case 13:
+		for _, ch := range isbn {
+			if ch < '0' || ch > '9' {
+				return "", fmt.Errorf("ISBN may only contain digits (and a final X for ISBN-10)")
+			}
+		}
+		if !strings.HasPrefix(isbn, "978") && !strings.HasPrefix(isbn, "979") {
+			return "", fmt.Errorf("ISBN-13 must start with 978 or 979")
+		}
+		if isbn13CheckDigit(isbn[:12]) != isbn[12:] {
+			return "", fmt.Errorf("invalid ISBN-13 checksum")
+		}
+		return isbn, nil
+	}
+
+	return "", fmt.Errorf("ISBN must have 10 or 13 digits")
+}
+
+func isbn13CheckDigit(body string) string {
+	sum := 0
+	for i, ch := range body {
+		weight := 1
+		if i%2 == 1 {
+			weight = 3
+		}
+		sum += int(ch-'0') * weight
+	}
+	return fmt.Sprint((10 - sum%10) % 10)
+}
+
+// isbn13To10 converts a 978-prefixed ISBN-13 back to ISBN-10; 979 ISBNs have no ISBN-10 form
+func isbn13To10(isbn13 string) string {
+	if !strings.HasPrefix(isbn13, "978") {
+		return ""
+	}
+	body := isbn13[3:12]
+	sum := 0
+	for i, ch := range body {
+		sum += int(ch-'0') * (10 - i)
+	}
+	check := (11 - sum%11) % 11
+	if check == 10 {
+		return body + "X"
+	}
+	return body + fmt.Sprint(check)
+}
+
+// normalizeCourseCode uppercases a course code and drops separators, so "math 101" matches "MATH-101"
+func normalizeCourseCode(raw string) string {
+	return strings.ToUpper(strings.NewReplacer(" ", "", "-", "", "_", "").Replace(strings.TrimSpace(raw)))
+}
+
+// bookIdentifiers normalizes the isbn and course_code attributes in place and returns them
+// for the products.isbn and products.course_code search columns
+func bookIdentifiers(attributes map[string]interface{}) (string, string, error) {
+	var isbn, courseCode string
+	if raw, ok := attributes["isbn"].(string); ok && raw != "" {
+		normalized, err := normalizeISBN(raw)
+		if err != nil {
+			return "", "", err
+		}
+		isbn = normalized
+		attributes["isbn"] = isbn
+	}
+	if raw, ok := attributes["course_code"].(string); ok && raw != "" {
+		courseCode = normalizeCourseCode(raw)
+		attributes["course_code"] = courseCode
+	}
+	return isbn, courseCode, nil
+}
+
+// bookTitleForISBN returns the catalog title for an ISBN, or "" when it is unknown
+func bookTitleForISBN(isbn string) string {
+	if isbn == "" {
+		return ""
+	}
+	book, err := config.Books.LookupISBN(isbn)
+	if err != nil {
+		return ""
+	}
+	return book.Title
+}
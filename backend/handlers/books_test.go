@@ -0,0 +1,49 @@
+package handlers
+
+import "testing"
+
+func TestNormalizeISBN(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{"9780306406157", "9780306406157", false},
+		{"978-0-306-40615-7", "9780306406157", false},
+		{"  ISBN: 978 0 306 40615 7 ", "9780306406157", false},
+		{"isbn9780306406157", "9780306406157", false},
+		{"0306406152", "9780306406157", false},
+		{"0-8044-2957-X", "9780804429573", false},
+		{"080442957x", "9780804429573", false},
+		{"9791090636071", "9791090636071", false},
+		{"9780306406158", "", true}, // bad ISBN-13 check digit
+		{"0306406153", "", true},    // bad ISBN-10 check digit
+		{"X306406152", "", true},    // X only allowed as the ISBN-10 check digit
+		{"9770306406155", "", true}, // neither 978 nor 979
+		{"97803064061A7", "", true}, // letter in an ISBN-13
+		{"978030640615", "", true},  // 12 digits
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		got, err := normalizeISBN(tt.raw)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("normalizeISBN(%q) = %q, %v; want %q, error %v", tt.raw, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestISBN13To10(t *testing.T) {
+	tests := []struct {
+		isbn13, want string
+	}{
+		{"9780306406157", "0306406152"},
+		{"9780804429573", "080442957X"},
+		{"9781861972712", "1861972717"},
+		{"9791090636071", ""},
+	}
+	for _, tt := range tests {
+		if got := isbn13To10(tt.isbn13); got != tt.want {
+			t.Errorf("isbn13To10(%q) = %q, want %q", tt.isbn13, got, tt.want)
+		}
+	}
+}
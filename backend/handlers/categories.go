@@ -0,0 +1,418 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"marketplace-backend/config"
+	"marketplace-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const maxCategoryDepth = 4
+
+var slugInvalidChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// CategoryRequest for admin management of the category taxonomy
+type CategoryRequest struct {
+	Name                string                       `json:"name" binding:"required,max=80"`
+	Slug                string                       `json:"slug" binding:"max=80"`
+	ParentID            *uuid.UUID                   `json:"parent_id"`
+	Attributes          []models.AttributeDefinition `json:"attributes"`
+	DescriptionTemplate string                       `json:"description_template" binding:"max=500"`
+	SortOrder           int                          `json:"sort_order"`
+	IsActive            *bool                        `json:"is_active"`
+}
+
+// categoryTree is an in-memory view of the taxonomy; the table is small enough to load whole
+type categoryTree struct {
+	byID     map[uuid.UUID]models.Category
+	children map[uuid.UUID][]uuid.UUID
+	roots    []uuid.UUID
+}
+
+// GetCategories returns the active taxonomy as a tree; admins can pass ?include_inactive=true
+func GetCategories(c *gin.Context) {
+	tree, err := loadCategoryTree()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch categories"})
+		return
+	}
+
+	includeInactive := c.Query("include_inactive") == "true" && isAdminRequest(c)
+
+	categoryDTOs := make([]CategoryDTO, 0, len(tree.roots))
+	for _, id := range tree.roots {
+		if dto := tree.dto(id, includeInactive); dto != nil {
+			categoryDTOs = append(categoryDTOs, *dto)
+		}
+	}
+
+	c.JSON(http.StatusOK, categoryDTOs)
+}
+
+// GetCategory returns one category by ID or slug, with its subtree
+func GetCategory(c *gin.Context) {
+	category := config.FindCategory(c.Param("id"))
+	if category == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return
+	}
+
+	tree, err := loadCategoryTree()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch categories"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tree.dto(category.ID, true))
+}
+
+// CreateCategory adds a category to the taxonomy
+func CreateCategory(c *gin.Context) {
+	var req CategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tree, err := loadCategoryTree()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch categories"})
+		return
+	}
+
+	category := models.Category{IsActive: true}
+	if err := tree.applyRequest(&category, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if tree.slugTaken(category.Slug, uuid.Nil) {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Slug %q is already in use", category.Slug)})
+		return
+	}
+
+	if err := config.DB.Create(&category).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create category"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, category)
+}
+
+// UpdateCategory renames, moves or changes the requirements of a category
+func UpdateCategory(c *gin.Context) {
+	categoryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+		return
+	}
+
+	var category models.Category
+	if err := config.DB.First(&category, categoryID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return
+	}
+
+	var req CategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tree, err := loadCategoryTree()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch categories"})
+		return
+	}
+
+	oldName := category.Name
+	if err := tree.applyRequest(&category, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if tree.slugTaken(category.Slug, category.ID) {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Slug %q is already in use", category.Slug)})
+		return
+	}
+
+	if err := config.DB.Select("*").Save(&category).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update category"})
+		return
+	}
+
+	// Keep the denormalized name on listings in step with a rename
+	if category.Name != oldName {
+		config.DB.Model(&models.Product{}).Where("category_id = ?", category.ID).Update("category", category.Name)
+	}
+
+	c.JSON(http.StatusOK, category)
+}
+
+// DeleteCategory deactivates a category and its subtree so existing listings keep their category
+func DeleteCategory(c *gin.Context) {
+	categoryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+		return
+	}
+
+	tree, err := loadCategoryTree()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch categories"})
+		return
+	}
+	if _, ok := tree.byID[categoryID]; !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return
+	}
+
+	result := config.DB.Model(&models.Category{}).Where("id IN ?", tree.subtree(categoryID)).Update("is_active", false)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove category"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Category removed successfully"})
+}
+
+// resolveProductCategory finds the active category a listing is being filed under and checks
+// its attributes against the schema of the category and its ancestors, returning them coerced
+func resolveProductCategory(ref string, attributes map[string]interface{}) (*models.Category, map[string]interface{}, error) {
+	category := config.FindCategory(ref)
+	if category == nil || !category.IsActive {
+		return nil, nil, fmt.Errorf("unknown category %q, see GET /api/categories for valid values", strings.TrimSpace(ref))
+	}
+
+	tree, err := loadCategoryTree()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load categories")
+	}
+
+	validated, err := validateProductAttributes(tree.attributeSchema(category.ID), attributes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %v", category.Name, err)
+	}
+
+	return category, validated, nil
+}
+
+// categoryFilterIDs returns the category matched by a filter value and all of its descendants,
+// or nil when the value isn't in the taxonomy
+func categoryFilterIDs(ref string) []uuid.UUID {
+	category := config.FindCategory(ref)
+	if category == nil {
+		return nil
+	}
+	tree, err := loadCategoryTree()
+	if err != nil {
+		return []uuid.UUID{category.ID}
+	}
+	return tree.subtree(category.ID)
+}
+
+// categoryMatchKeys returns the lowercase names and slugs of a product's category and its ancestors
+func categoryMatchKeys(product *models.Product) []string {
+	keys := []string{strings.ToLower(product.Category)}
+	if product.CategoryID == nil {
+		return keys
+	}
+	tree, err := loadCategoryTree()
+	if err != nil {
+		return keys
+	}
+	for _, category := range tree.ancestry(*product.CategoryID) {
+		keys = append(keys, strings.ToLower(category.Name), category.Slug)
+	}
+	return keys
+}
+
+func loadCategoryTree() (*categoryTree, error) {
+	var categories []models.Category
+	if err := config.DB.Order("sort_order ASC, name ASC").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+
+	tree := &categoryTree{
+		byID:     make(map[uuid.UUID]models.Category, len(categories)),
+		children: map[uuid.UUID][]uuid.UUID{},
+	}
+	for _, category := range categories {
+		tree.byID[category.ID] = category
+	}
+	for _, category := range categories {
+		if category.ParentID != nil {
+			if _, ok := tree.byID[*category.ParentID]; ok {
+				tree.children[*category.ParentID] = append(tree.children[*category.ParentID], category.ID)
+				continue
+			}
+		}
+		tree.roots = append(tree.roots, category.ID)
+	}
+	return tree, nil
+}
+
+// ancestry returns the path from the root down to the category
+func (t *categoryTree) ancestry(id uuid.UUID) []models.Category {
+	var path []models.Category
+	for depth := 0; depth <= maxCategoryDepth; depth++ {
+		category, ok := t.byID[id]
+		if !ok {
+			break
+		}
+		path = append([]models.Category{category}, path...)
+		if category.ParentID == nil {
+			break
+		}
+		id = *category.ParentID
+	}
+	return path
+}
+
+// subtree returns the category and all of its descendants
+func (t *categoryTree) subtree(id uuid.UUID) []uuid.UUID {
+	ids := []uuid.UUID{id}
+	for i := 0; i < len(ids); i++ {
+		ids = append(ids, t.children[ids[i]]...)
+	}
+	return ids
+}
+
+// attributeSchema merges the schemas of the category and its ancestors; a subcategory can
+// redefine an inherited attribute, e.g. to make it required
+func (t *categoryTree) attributeSchema(id uuid.UUID) []models.AttributeDefinition {
+	schema := []models.AttributeDefinition{}
+	index := map[string]int{}
+	for _, category := range t.ancestry(id) {
+		for _, def := range decodeAttributeSchema(category.AttributeSchema) {
+			if i, ok := index[def.Key]; ok {
+				schema[i] = def
+				continue
+			}
+			index[def.Key] = len(schema)
+			schema = append(schema, def)
+		}
+	}
+	return schema
+}
+
+func (t *categoryTree) slugTaken(slug string, exceptID uuid.UUID) bool {
+	for id, category := range t.byID {
+		if category.Slug == slug && id != exceptID {
+			return true
+		}
+	}
+	return false
+}
+
+// applyRequest validates an admin request against the tree and copies it onto the category
+func (t *categoryTree) applyRequest(category *models.Category, req *CategoryRequest) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return fmt.Errorf("name is required")
+	}
+
+	if req.ParentID != nil {
+		if _, ok := t.byID[*req.ParentID]; !ok {
+			return fmt.Errorf("parent category not found")
+		}
+		if category.ID != uuid.Nil {
+			for _, id := range t.subtree(category.ID) {
+				if id == *req.ParentID {
+					return fmt.Errorf("a category can't be moved under itself or one of its subcategories")
+				}
+			}
+		}
+		if len(t.ancestry(*req.ParentID)) >= maxCategoryDepth {
+			return fmt.Errorf("categories can be nested at most %d levels deep", maxCategoryDepth)
+		}
+	}
+
+	template := strings.TrimSpace(req.DescriptionTemplate)
+	if template != "" && (strings.Count(template, "%") != 1 || !strings.Contains(template, "%s")) {
+		return fmt.Errorf("description_template must contain %%s exactly once for the title")
+	}
+
+	slug := slugify(req.Slug)
+	if slug == "" {
+		slug = slugify(name)
+		if req.ParentID != nil && t.slugTaken(slug, category.ID) {
+			slug = slugify(t.byID[*req.ParentID].Slug + " " + name)
+		}
+	}
+	if slug == "" {
+		return fmt.Errorf("slug must contain letters or digits")
+	}
+
+	schema, err := normalizeAttributeSchema(req.Attributes)
+	if err != nil {
+		return err
+	}
+	schemaJSON, _ := json.Marshal(schema)
+
+	category.Name = name
+	category.Slug = slug
+	category.ParentID = req.ParentID
+	category.AttributeSchema = string(schemaJSON)
+	category.DescriptionTemplate = template
+	category.SortOrder = req.SortOrder
+	if req.IsActive != nil {
+		category.IsActive = *req.IsActive
+	}
+	return nil
+}
+
+// dto builds the API form of a category and its (optionally only active) children
+func (t *categoryTree) dto(id uuid.UUID, includeInactive bool) *CategoryDTO {
+	category, ok := t.byID[id]
+	if !ok || (!category.IsActive && !includeInactive) {
+		return nil
+	}
+
+	names := []string{}
+	for _, ancestor := range t.ancestry(id) {
+		names = append(names, ancestor.Name)
+	}
+
+	dto := &CategoryDTO{
+		ID:         category.ID.String(),
+		Name:       category.Name,
+		Slug:       category.Slug,
+		Path:       strings.Join(names, " > "),
+		Attributes: t.attributeSchema(id),
+		IsActive:   category.IsActive,
+		Children:   []CategoryDTO{},
+	}
+	if category.ParentID != nil {
+		dto.ParentID = category.ParentID.String()
+	}
+	for _, childID := range t.children[id] {
+		if child := t.dto(childID, includeInactive); child != nil {
+			dto.Children = append(dto.Children, *child)
+		}
+	}
+	return dto
+}
+
+func normalizeAttributeKey(key string) string {
+	return strings.Trim(slugInvalidChars.ReplaceAllString(strings.ToLower(strings.TrimSpace(key)), "_"), "_")
+}
+
+func slugify(s string) string {
+	return strings.Trim(slugInvalidChars.ReplaceAllString(strings.ToLower(strings.TrimSpace(s)), "-"), "-")
+}
+
+// isAdminRequest reports whether the (optionally) authenticated caller is an admin
+func isAdminRequest(c *gin.Context) bool {
+	userID, exists := c.Get("userID")
+	if !exists {
+		return false
+	}
+	var user models.User
+	return config.DB.Select("is_admin").First(&user, userID).Error == nil && user.IsAdmin
+}
@@ -9,22 +9,36 @@ import (
 	"github.com/google/uuid"
 )
 
-// GetChats returns all chats for a user (college-filtered)
+// GetChats returns the chats the authenticated user takes part in
 func GetChats(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
 	var chats []models.Chat
-	
-	// For now, get all chats (later filter by user's college)
-	result := config.DB.Preload("Product").Preload("Participants").Preload("Messages.From").Find(&chats)
+	result := config.DB.Preload("Product", withDeleted).Preload("Participants", withDeleted).Preload("Messages.From", withDeleted).
+		Joins("JOIN chat_participants ON chat_participants.chat_id = chats.id").
+		Where("chat_participants.user_id = ?", userID).
+		Find(&chats)
 	if result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch chats"})
 		return
 	}
+	markRemovedListings(chats)
 
-	c.JSON(http.StatusOK, chats)
+	c.JSON(http.StatusOK, ChatDTOsFromModels(chats))
 }
 
-// GetChat returns a specific chat with messages
+// GetChat returns a specific chat with messages; chats the caller isn't part of are not found
 func GetChat(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
 	id := c.Param("id")
 	chatID, err := uuid.Parse(id)
 	if err != nil {
@@ -33,36 +47,58 @@ func GetChat(c *gin.Context) {
 	}
 
 	var chat models.Chat
-	result := config.DB.Preload("Product").Preload("Participants").Preload("Messages.From").First(&chat, chatID)
-	if result.Error != nil {
+	result := config.DB.Preload("Product", withDeleted).Preload("Participants", withDeleted).Preload("Messages.From", withDeleted).First(&chat, chatID)
+	if result.Error != nil || !isChatParticipant(chatID, userID) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Chat not found"})
 		return
 	}
+	chat.ListingRemoved = chat.Product.DeletedAt.Valid
 
-	c.JSON(http.StatusOK, chat)
+	c.JSON(http.StatusOK, ChatDTOFromModel(&chat))
 }
 
-// GetChatMessages returns messages for a specific chat
+// GetChatMessages returns messages for a specific chat the caller takes part in
 func GetChatMessages(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
 	id := c.Param("id")
 	chatID, err := uuid.Parse(id)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid chat ID"})
 		return
 	}
+	if !isChatParticipant(chatID, userID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Chat not found"})
+		return
+	}
 
 	var messages []models.Message
-	result := config.DB.Preload("From").Where("chat_id = ?", chatID).Order("created_at ASC").Find(&messages)
+	result := config.DB.Preload("From", withDeleted).Where("chat_id = ?", chatID).Order("created_at ASC").Find(&messages)
 	if result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch messages"})
 		return
 	}
 
-	c.JSON(http.StatusOK, messages)
+	c.JSON(http.StatusOK, MessageDTOsFromModels(messages))
 }
 
-// CreateMessage creates a new message in a chat
+// CreateMessageRequest is the body of a new chat message
+type CreateMessageRequest struct {
+	Text string `json:"text" binding:"required"`
+}
+
+// CreateMessage creates a new message in a chat, sent by the authenticated user
 func CreateMessage(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
 	id := c.Param("id")
 	chatID, err := uuid.Parse(id)
 	if err != nil {
@@ -70,24 +106,42 @@ func CreateMessage(c *gin.Context) {
 		return
 	}
 
-	var message models.Message
-	if err := c.ShouldBindJSON(&message); err != nil {
+	var req CreateMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
 	var chat models.Chat
-	if err := config.DB.First(&chat, chatID).Error; err != nil {
+	if err := config.DB.Preload("Participants", withDeleted).Preload("Product", withDeleted).First(&chat, chatID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Chat not found"})
 		return
 	}
 
+	// Only the text comes from the body; the sender is always the caller and system messages are app-only
+	message := models.Message{ChatID: chatID, FromID: userID.(uuid.UUID), Text: req.Text}
+	isParticipant := false
+	for _, participant := range chat.Participants {
+		if participant.ID == message.FromID {
+			isParticipant = true
+			break
+		}
+	}
+	if !isParticipant {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You are not part of this chat"})
+		return
+	}
+
 	if !chat.IsAccepted {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Chat not accepted by seller"})
 		return
 	}
-
-	message.ChatID = chatID
+	for _, participant := range chat.Participants {
+		if participant.ID != message.FromID && usersBlocked(message.FromID, participant.ID) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You cannot message this user"})
+			return
+		}
+	}
 
 	result := config.DB.Create(&message)
 	if result.Error != nil {
@@ -96,7 +150,27 @@ func CreateMessage(c *gin.Context) {
 	}
 
 	// Preload relationships for response
-	config.DB.Preload("From").First(&message, message.ID)
+	config.DB.Preload("From", withDeleted).First(&message, message.ID)
+
+	for _, participant := range chat.Participants {
+		if participant.ID == message.FromID {
+			continue
+		}
+		notify(NotificationEvent{
+			UserID: participant.ID,
+			Type:   NotificationEventMessageCreated,
+			Title:  "New message from " + message.From.Name,
+			Body:   truncateText(message.Text, 140),
+			Link:   "/chats",
+		})
+	}
+
+	c.JSON(http.StatusCreated, MessageDTOFromModel(&message))
+}
 
-	c.JSON(http.StatusCreated, message)
+// isChatParticipant reports whether the user takes part in the chat
+func isChatParticipant(chatID uuid.UUID, userID interface{}) bool {
+	var count int64
+	config.DB.Table("chat_participants").Where("chat_id = ? AND user_id = ?", chatID, userID).Count(&count)
+	return count > 0
 }
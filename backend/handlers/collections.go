@@ -0,0 +1,364 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"marketplace-backend/config"
+	"marketplace-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const maxCollectionsPerUser = 50
+
+// CollectionRequest creates or renames a wishlist collection
+type CollectionRequest struct {
+	Name        string `json:"name" binding:"required,max=80"`
+	Description string `json:"description" binding:"max=500"`
+}
+
+// GetCollections returns the caller's collections with their products
+func GetCollections(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var collections []models.WishlistCollection
+	result := preloadCollectionItems(config.DB, visibleListings(c)).Where("user_id = ?", userID).Order("created_at ASC").Find(&collections)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch collections"})
+		return
+	}
+
+	collectionDTOs := make([]CollectionDTO, 0, len(collections))
+	for i := range collections {
+		collectionDTOs = append(collectionDTOs, *CollectionDTOFromModel(&collections[i]))
+	}
+
+	c.JSON(http.StatusOK, collectionDTOs)
+}
+
+// GetCollection returns one of the caller's collections
+func GetCollection(c *gin.Context) {
+	collection, ok := loadOwnCollection(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, CollectionDTOFromModel(collection))
+}
+
+// CreateCollection creates a new named collection
+func CreateCollection(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req CollectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var count int64
+	config.DB.Model(&models.WishlistCollection{}).Where("user_id = ?", userID).Count(&count)
+	if count >= maxCollectionsPerUser {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("You can have at most %d collections", maxCollectionsPerUser)})
+		return
+	}
+
+	collection := models.WishlistCollection{
+		UserID:      userID.(uuid.UUID),
+		Name:        strings.TrimSpace(req.Name),
+		Description: req.Description,
+	}
+	if err := config.DB.Create(&collection).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create collection"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, CollectionDTOFromModel(&collection))
+}
+
+// UpdateCollection renames a collection or changes its description
+func UpdateCollection(c *gin.Context) {
+	collection, ok := loadOwnCollection(c)
+	if !ok {
+		return
+	}
+
+	var req CollectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := config.DB.Model(collection).Updates(map[string]interface{}{
+		"name":        strings.TrimSpace(req.Name),
+		"description": req.Description,
+	})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update collection"})
+		return
+	}
+
+	c.JSON(http.StatusOK, CollectionDTOFromModel(collection))
+}
+
+// DeleteCollection deletes a collection; the products stay in the caller's favorites
+func DeleteCollection(c *gin.Context) {
+	collection, ok := loadOwnCollection(c)
+	if !ok {
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("collection_id = ?", collection.ID).Delete(&models.CollectionItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(collection).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete collection"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Collection deleted successfully"})
+}
+
+// AddCollectionItem files a product in a collection, favoriting it if needed
+func AddCollectionItem(c *gin.Context) {
+	collection, ok := loadOwnCollection(c)
+	if !ok {
+		return
+	}
+
+	productID, err := uuid.Parse(c.Param("productId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	var product models.Product
+	if err := config.DB.Preload("Seller").First(&product, productID).Error; err != nil || !productVisibleTo(c, &product) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+
+	var favorite models.Favorite
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if tx.Where("user_id = ? AND product_id = ?", collection.UserID, productID).First(&favorite).Error != nil {
+			favorite = models.Favorite{UserID: collection.UserID, ProductID: productID, PriceWhenFavorited: product.Price}
+			if err := tx.Create(&favorite).Error; err != nil {
+				return err
+			}
+		}
+		return addToCollections(tx, collection.UserID, productID, []uuid.UUID{collection.ID})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add product to collection"})
+		return
+	}
+
+	preloadCollectionItems(config.DB, visibleListings(c)).First(collection, collection.ID)
+
+	c.JSON(http.StatusOK, CollectionDTOFromModel(collection))
+}
+
+// RemoveCollectionItem takes a product out of a collection; it stays favorited
+func RemoveCollectionItem(c *gin.Context) {
+	collection, ok := loadOwnCollection(c)
+	if !ok {
+		return
+	}
+
+	productID, err := uuid.Parse(c.Param("productId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	result := config.DB.Where("collection_id = ? AND product_id = ?", collection.ID, productID).Delete(&models.CollectionItem{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove product from collection"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product is not in this collection"})
+		return
+	}
+
+	preloadCollectionItems(config.DB, visibleListings(c)).First(collection, collection.ID)
+
+	c.JSON(http.StatusOK, CollectionDTOFromModel(collection))
+}
+
+// ShareCollection creates (or returns) the read-only public link for a collection
+func ShareCollection(c *gin.Context) {
+	collection, ok := loadOwnCollection(c)
+	if !ok {
+		return
+	}
+
+	if collection.ShareToken == nil {
+		token, err := newShareToken()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create share link"})
+			return
+		}
+		if err := config.DB.Model(collection).Update("share_token", token).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create share link"})
+			return
+		}
+		collection.ShareToken = &token
+	}
+
+	c.JSON(http.StatusOK, CollectionDTOFromModel(collection))
+}
+
+// UnshareCollection revokes the public link; old links stop working
+func UnshareCollection(c *gin.Context) {
+	collection, ok := loadOwnCollection(c)
+	if !ok {
+		return
+	}
+
+	if err := config.DB.Model(collection).Update("share_token", nil).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke share link"})
+		return
+	}
+	collection.ShareToken = nil
+
+	c.JSON(http.StatusOK, CollectionDTOFromModel(collection))
+}
+
+// GetSharedCollection returns a shared collection by its public token, without owner details. Only listings
+// anyone can browse are shown, leaving out those of sellers the owner blocked or was blocked by.
+func GetSharedCollection(c *gin.Context) {
+	token := c.Param("token")
+
+	var collection models.WishlistCollection
+	if err := config.DB.Where("share_token = ?", token).First(&collection).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found"})
+		return
+	}
+	publicListings := func(db *gorm.DB) *gorm.DB {
+		return db.Scopes(visibleListingsTo(collection.UserID)).Where("products.status IN ?", publicProductStatuses)
+	}
+	if err := preloadCollectionItems(config.DB, publicListings).First(&collection, collection.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch collection"})
+		return
+	}
+
+	dto := CollectionDTOFromModel(&collection)
+	dto.ShareURL = ""
+	c.JSON(http.StatusOK, dto)
+}
+
+// CollectionDTOFromModel converts a collection (with preloaded items) to its API form
+func CollectionDTOFromModel(collection *models.WishlistCollection) *CollectionDTO {
+	dto := &CollectionDTO{
+		ID:          collection.ID.String(),
+		Name:        collection.Name,
+		Description: collection.Description,
+		IsShared:    collection.ShareToken != nil,
+		Products:    make([]ProductDTO, 0, len(collection.Items)),
+		CreatedAt:   collection.CreatedAt.Format("2006-01-02T15:04:05.000Z"),
+	}
+	if collection.ShareToken != nil {
+		dto.ShareURL = config.GetFrontendURL() + "/collections/shared/" + *collection.ShareToken
+	}
+	for i := range collection.Items {
+		// Deleted listings and those the viewer can't see are not preloaded; they disappear until visible again
+		if collection.Items[i].Product.ID == uuid.Nil {
+			continue
+		}
+		dto.Products = append(dto.Products, *ProductDTOFromModel(&collection.Items[i].Product))
+	}
+	dto.ItemCount = len(dto.Products)
+	return dto
+}
+
+func loadOwnCollection(c *gin.Context) (*models.WishlistCollection, bool) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return nil, false
+	}
+
+	collectionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid collection ID"})
+		return nil, false
+	}
+
+	var collection models.WishlistCollection
+	if err := preloadCollectionItems(config.DB, visibleListings(c)).Where("id = ? AND user_id = ?", collectionID, userID).First(&collection).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found"})
+		return nil, false
+	}
+
+	return &collection, true
+}
+
+// preloadCollectionItems preloads the items of collections with the products the visible scope lets through;
+// the other items keep an empty Product and are left out of the DTO
+func preloadCollectionItems(db *gorm.DB, visible func(db *gorm.DB) *gorm.DB) *gorm.DB {
+	return db.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at DESC")
+	}).Preload("Items.Product", visible).Preload("Items.Product.Seller")
+}
+
+// errCollectionsNotFound is returned by addToCollections when a collection doesn't exist or isn't the user's
+var errCollectionsNotFound = errors.New("one or more collections were not found")
+
+// addToCollections files a product in the given collections, which must all belong to the user
+func addToCollections(db *gorm.DB, userID, productID uuid.UUID, collectionIDs []uuid.UUID) error {
+	if len(collectionIDs) == 0 {
+		return nil
+	}
+
+	seen := map[uuid.UUID]bool{}
+	unique := collectionIDs[:0:0]
+	for _, id := range collectionIDs {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	collectionIDs = unique
+
+	var owned int64
+	if err := db.Model(&models.WishlistCollection{}).Where("id IN ? AND user_id = ?", collectionIDs, userID).Count(&owned).Error; err != nil {
+		return err
+	}
+	if int(owned) != len(collectionIDs) {
+		return errCollectionsNotFound
+	}
+
+	items := make([]models.CollectionItem, 0, len(collectionIDs))
+	for _, collectionID := range collectionIDs {
+		items = append(items, models.CollectionItem{CollectionID: collectionID, ProductID: productID})
+	}
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&items).Error
+}
+
+func newShareToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
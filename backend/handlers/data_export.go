@@ -0,0 +1,312 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"marketplace-backend/config"
+	"marketplace-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// dataExportRetryAfter keeps a failing export from being rebuilt on every poll
+	dataExportRetryAfter = 15 * time.Minute
+	dataExportStalled    = 30 * time.Minute
+	exportTimeFormat     = "2006-01-02T15:04:05.000Z"
+)
+
+// DataExportResponse is an export's status plus its download link once it is ready
+type DataExportResponse struct {
+	models.DataExport
+	DownloadURL string `json:"download_url,omitempty"`
+}
+
+// GetMyDataExport returns the caller's latest data export, starting a new one when there is none to
+// wait for or download. Poll until status is "ready" (200 with download_url); 202 means it is still building.
+func GetMyDataExport(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var latest models.DataExport
+	found := config.DB.Where("user_id = ?", userID).Order("created_at DESC").First(&latest).Error == nil
+	if found {
+		switch {
+		case latest.Status == "pending" || latest.Status == "processing":
+			c.JSON(http.StatusAccepted, DataExportResponse{DataExport: latest})
+			return
+		case latest.Status == "ready" && latest.ExpiresAt != nil && latest.ExpiresAt.After(time.Now()):
+			url, err := config.BlobSASURL(config.ExportsContainer, latest.BlobName, *latest.ExpiresAt)
+			if err != nil {
+				log.Printf("Failed to sign data export %s: %v", latest.ID, err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create download link"})
+				return
+			}
+			c.JSON(http.StatusOK, DataExportResponse{DataExport: latest, DownloadURL: url})
+			return
+		case latest.Status == "failed" && time.Since(latest.UpdatedAt) < dataExportRetryAfter:
+			c.JSON(http.StatusOK, DataExportResponse{DataExport: latest})
+			return
+		}
+	}
+
+	// A partial unique index allows one pending or processing export per user; if a concurrent poll
+	// got there first, report that export instead of building a second one
+	export := models.DataExport{UserID: userID.(uuid.UUID), Status: "pending"}
+	result := config.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&export)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start data export"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		if err := config.DB.Where("user_id = ? AND status IN ?", userID, []string{"pending", "processing"}).First(&latest).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start data export"})
+			return
+		}
+		c.JSON(http.StatusAccepted, DataExportResponse{DataExport: latest})
+		return
+	}
+
+	go buildDataExport(export)
+
+	c.JSON(http.StatusAccepted, DataExportResponse{DataExport: export})
+}
+
+// buildDataExport assembles the archive, uploads it to the private exports container and tells the user
+func buildDataExport(export models.DataExport) {
+	fail := func(err error) {
+		log.Printf("Data export %s failed: %v", export.ID, err)
+		config.DB.Model(&export).Updates(map[string]interface{}{"status": "failed", "error": "The export could not be created, try again later"})
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			fail(fmt.Errorf("panic: %v", r))
+		}
+	}()
+
+	config.DB.Model(&export).Update("status", "processing")
+
+	archive, err := assembleDataExport(export.UserID)
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	blobName := fmt.Sprintf("users/%s/%s.zip", export.UserID, export.ID)
+	if err := config.UploadBlob(config.ExportsContainer, blobName, archive, "application/zip"); err != nil {
+		fail(err)
+		return
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(config.DataExportTTL())
+	err = config.DB.Model(&export).Updates(map[string]interface{}{
+		"status":       "ready",
+		"blob_name":    blobName,
+		"size_bytes":   len(archive),
+		"expires_at":   expiresAt,
+		"completed_at": now,
+	}).Error
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	notify(NotificationEvent{
+		UserID: export.UserID,
+		Type:   NotificationEventDataExportReady,
+		Title:  "Your data export is ready",
+		Body:   fmt.Sprintf("Download it before %s UTC, after which it is deleted.", expiresAt.UTC().Format("Jan 2 15:04")),
+		Link:   "/profile",
+	})
+}
+
+// assembleDataExport zips the user's profile, listings, favorites, purchase requests and chat messages,
+// each as JSON and (except the profile) as CSV
+func assembleDataExport(userID uuid.UUID) ([]byte, error) {
+	var user models.User
+	if err := config.DB.Preload("College").First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+
+	var products []models.Product
+	if err := config.DB.Unscoped().Where("seller_id = ?", userID).Order("created_at ASC").Find(&products).Error; err != nil {
+		return nil, err
+	}
+
+	var favorites []models.Favorite
+	if err := config.DB.Preload("Product", withDeleted).Where("user_id = ?", userID).Order("created_at ASC").Find(&favorites).Error; err != nil {
+		return nil, err
+	}
+
+	var requests []models.PurchaseRequest
+	err := config.DB.Preload("Product", withDeleted).Preload("Buyer", withDeleted).Preload("Seller", withDeleted).
+		Where("buyer_id = ? OR seller_id = ?", userID, userID).
+		Order("created_at ASC").
+		Find(&requests).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []models.Message
+	err = config.DB.Preload("From", withDeleted).
+		Joins("JOIN chat_participants ON chat_participants.chat_id = messages.chat_id").
+		Where("chat_participants.user_id = ?", userID).
+		Order("messages.chat_id, messages.created_at ASC").
+		Find(&messages).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w := exportWriter{zw: zw}
+
+	w.json("profile.json", SelfUserDTOFromModel(&user))
+
+	productDTOs := make([]ProductDTO, 0, len(products))
+	productRows := make([][]string, 0, len(products))
+	for i := range products {
+		p := &products[i]
+		productDTOs = append(productDTOs, *ProductDTOFromModel(p))
+		deletedAt := ""
+		if p.DeletedAt.Valid {
+			deletedAt = p.DeletedAt.Time.Format(exportTimeFormat)
+		}
+		productRows = append(productRows, []string{
+			p.ID.String(), p.Title, formatExportPrice(p.Price), p.Condition, p.Category, p.Status,
+			p.CreatedAt.Format(exportTimeFormat), deletedAt,
+		})
+	}
+	w.json("products.json", productDTOs)
+	w.csv("products.csv", []string{"id", "title", "price", "condition", "category", "status", "created_at", "deleted_at"}, productRows)
+
+	favoriteRows := make([][]string, 0, len(favorites))
+	for _, f := range favorites {
+		favoriteRows = append(favoriteRows, []string{
+			f.ProductID.String(), f.Product.Title, formatExportPrice(f.PriceWhenFavorited), f.CreatedAt.Format(exportTimeFormat),
+		})
+	}
+	w.json("favorites.json", FavoriteDTOsFromModels(favorites))
+	w.csv("favorites.csv", []string{"product_id", "product_title", "price_when_favorited", "created_at"}, favoriteRows)
+
+	requestRows := make([][]string, 0, len(requests))
+	for _, r := range requests {
+		role, otherParty := "buyer", r.Seller.Name
+		if r.SellerID == userID {
+			role, otherParty = "seller", r.Buyer.Name
+		}
+		requestRows = append(requestRows, []string{
+			r.ID.String(), role, r.ProductID.String(), r.Product.Title, otherParty, r.Status, r.CreatedAt.Format(exportTimeFormat),
+		})
+	}
+	w.json("purchase_requests.json", PurchaseRequestDTOsFromModels(requests))
+	w.csv("purchase_requests.csv", []string{"id", "role", "product_id", "product_title", "other_party", "status", "created_at"}, requestRows)
+
+	messageRows := make([][]string, 0, len(messages))
+	for _, m := range messages {
+		messageRows = append(messageRows, []string{
+			m.ChatID.String(), m.ID.String(), m.From.Name, strconv.FormatBool(m.FromID == userID), m.Text, m.CreatedAt.Format(exportTimeFormat),
+		})
+	}
+	w.json("messages.json", MessageDTOsFromModels(messages))
+	w.csv("messages.csv", []string{"chat_id", "message_id", "from", "sent_by_you", "text", "created_at"}, messageRows)
+
+	if w.err != nil {
+		return nil, w.err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// exportWriter adds files to the export zip, keeping the first error
+type exportWriter struct {
+	zw  *zip.Writer
+	err error
+}
+
+func (w *exportWriter) json(name string, value interface{}) {
+	if w.err != nil {
+		return
+	}
+	f, err := w.zw.Create(name)
+	if err != nil {
+		w.err = err
+		return
+	}
+	encoder := json.NewEncoder(f)
+	encoder.SetIndent("", "  ")
+	w.err = encoder.Encode(value)
+}
+
+func (w *exportWriter) csv(name string, header []string, rows [][]string) {
+	if w.err != nil {
+		return
+	}
+	f, err := w.zw.Create(name)
+	if err != nil {
+		w.err = err
+		return
+	}
+	cw := csv.NewWriter(f)
+	cw.Write(header)
+	for _, row := range rows {
+		for i := range row {
+			row[i] = csvSafeCell(row[i])
+		}
+	}
+	cw.WriteAll(rows)
+	w.err = cw.Error()
+}
+
+// csvSafeCell quotes a cell a spreadsheet would run as a formula (CSV injection), since the exports
+// carry text other users wrote: message text, names and listing titles
+func csvSafeCell(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+func formatExportPrice(price float64) string {
+	return strconv.FormatFloat(price, 'f', 2, 64)
+}
+
+// expireDataExports deletes archives whose download window has passed and fails exports that stopped making progress
+func expireDataExports() {
+	var expired []models.DataExport
+	if err := config.DB.Where("status = ? AND expires_at <= ?", "ready", time.Now()).Find(&expired).Error; err != nil {
+		log.Printf("Failed to load expired data exports: %v", err)
+		return
+	}
+	for _, export := range expired {
+		if err := config.DeleteBlob(config.ExportsContainer, export.BlobName); err != nil {
+			log.Printf("Failed to delete data export %s: %v", export.ID, err)
+			continue
+		}
+		config.DB.Model(&export).Update("status", "expired")
+	}
+
+	result := config.DB.Model(&models.DataExport{}).
+		Where("status IN ? AND updated_at < ?", []string{"pending", "processing"}, time.Now().Add(-dataExportStalled)).
+		Updates(map[string]interface{}{"status": "failed", "error": "The export was interrupted, try again"})
+	if result.Error != nil {
+		log.Printf("Failed to mark stalled data exports: %v", result.Error)
+	}
+}
@@ -0,0 +1,25 @@
+package handlers
+
+import "testing"
+
+func TestCSVSafeCell(t *testing.T) {
+	tests := []struct {
+		cell, want string
+	}{
+		{"", ""},
+		{"Desk lamp", "Desk lamp"},
+		{"12.50", "12.50"},
+		{"2026-03-14T15:00:00.000Z", "2026-03-14T15:00:00.000Z"},
+		{`=HYPERLINK("http://example.com","click")`, `'=HYPERLINK("http://example.com","click")`},
+		{"+1 555 0100", "'+1 555 0100"},
+		{"-2+3", "'-2+3"},
+		{"@SUM(A1:A2)", "'@SUM(A1:A2)"},
+		{"\t=1+1", "'\t=1+1"},
+		{"a=b", "a=b"},
+	}
+	for _, tt := range tests {
+		if got := csvSafeCell(tt.cell); got != tt.want {
+			t.Errorf("csvSafeCell(%q) = %q, want %q", tt.cell, got, tt.want)
+		}
+	}
+}
@@ -2,7 +2,10 @@ package handlers
 
 import (
 	"encoding/json"
+	"time"
 	"marketplace-backend/models"
+
+	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
@@ -15,23 +18,123 @@ type ProductDTO struct {
 	Images      []string `json:"images"`
 	Condition   string   `json:"condition"`
 	Category    string   `json:"category"`
+	CategoryID  string   `json:"categoryId,omitempty"`
+	Attributes  map[string]interface{} `json:"attributes,omitempty"`
+	ISBN        string   `json:"isbn,omitempty"`
+	CourseCode  string   `json:"courseCode,omitempty"`
 	Tags        []string `json:"tags"`
 	Status      string   `json:"status"`
 	SellerID    string   `json:"sellerId"`
 	PostedAt    string   `json:"postedAt"`
+	PublishAt   string   `json:"publishAt,omitempty"`
+	ExpiresAt   string   `json:"expiresAt,omitempty"`
+	DeletedAt   string   `json:"deletedAt,omitempty"`
 	Seller      *SellerDTO `json:"seller,omitempty"`
+	// PreviousPrice is set when the current price is a drop from the last listed price
+	PreviousPrice *float64 `json:"previousPrice,omitempty"`
 }
 
 // SellerDTO for seller information in product responses
 type SellerDTO struct {
 	ID         string `json:"id"`
 	Name       string `json:"name"`
-	Email      string `json:"email"`
 	Year       string `json:"year"`
 	Department string `json:"department"`
 	Avatar     string `json:"avatar"`
 }
 
+// PublicUserDTO is the profile anyone may see; it never carries the email address or admin flag
+type PublicUserDTO struct {
+	ID         uuid.UUID       `json:"id"`
+	Name       string          `json:"name"`
+	Avatar     string          `json:"avatar"`
+	Year       string          `json:"year"`
+	Department string          `json:"department"`
+	CollegeID  uuid.UUID       `json:"college_id"`
+	College    *models.College `json:"college,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// SelfUserDTO is the signed-in user's own account
+type SelfUserDTO struct {
+	PublicUserDTO
+	Email     string    `json:"email"`
+	IsAdmin   bool      `json:"is_admin"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// AdminUserDTO is what admins see of any account
+type AdminUserDTO struct {
+	SelfUserDTO
+	HasPassword    bool       `json:"has_password"`
+	DeletedAt      *time.Time `json:"deleted_at,omitempty"`
+	HiddenAt       *time.Time `json:"hidden_at,omitempty"`
+	SuspendedUntil *time.Time `json:"suspended_until,omitempty"`
+	BannedAt       *time.Time `json:"banned_at,omitempty"`
+	StatusReason   string     `json:"status_reason,omitempty"`
+}
+
+// ListingDTO is a product as nested in chats, purchase requests and favorites, with the seller reduced to a public profile
+type ListingDTO struct {
+	models.Product
+	Seller *PublicUserDTO `json:"seller,omitempty"`
+}
+
+// ChatDTO is a chat whose participants and message senders are public profiles
+type ChatDTO struct {
+	models.Chat
+	Product      ListingDTO      `json:"product"`
+	Participants []PublicUserDTO `json:"participants"`
+	Messages     []MessageDTO    `json:"messages"`
+}
+
+// MessageDTO is a chat message with its sender as a public profile
+type MessageDTO struct {
+	models.Message
+	Chat   *ChatDTO      `json:"chat,omitempty"`
+	From   PublicUserDTO `json:"from"`
+	Hidden bool          `json:"hidden,omitempty"` // hidden by moderation; Text is replaced
+}
+
+// PurchaseRequestDTO is a purchase request with buyer and seller as public profiles
+type PurchaseRequestDTO struct {
+	models.PurchaseRequest
+	Product ListingDTO    `json:"product"`
+	Buyer   PublicUserDTO `json:"buyer"`
+	Seller  PublicUserDTO `json:"seller"`
+}
+
+// FavoriteDTO is a favorite with its product's seller as a public profile
+type FavoriteDTO struct {
+	models.Favorite
+	User    *PublicUserDTO `json:"user,omitempty"`
+	Product *ListingDTO    `json:"product,omitempty"`
+}
+
+// CollectionDTO for wishlist collection responses
+type CollectionDTO struct {
+	ID          string       `json:"id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	IsShared    bool         `json:"isShared"`
+	ShareURL    string       `json:"shareUrl,omitempty"`
+	ItemCount   int          `json:"itemCount"`
+	Products    []ProductDTO `json:"products"`
+	CreatedAt   string       `json:"createdAt"`
+}
+
+// CategoryDTO for the category tree; Attributes includes those inherited from parents
+type CategoryDTO struct {
+	ID         string                       `json:"id"`
+	ParentID   string                       `json:"parentId,omitempty"`
+	Name       string                       `json:"name"`
+	Slug       string                       `json:"slug"`
+	Path       string                       `json:"path"`
+	Attributes []models.AttributeDefinition `json:"attributes"`
+	IsActive   bool                         `json:"isActive"`
+	Children   []CategoryDTO                `json:"children"`
+}
+
 // CreateProductRequest for handling product creation
 type CreateProductRequest struct {
 	Title       string   `json:"title" binding:"required"`
@@ -74,6 +177,11 @@ func ProductDTOFromModel(product *models.Product) *ProductDTO {
 	json.Unmarshal([]byte(product.Images), &images)
 	json.Unmarshal([]byte(product.Tags), &tags)
 	
+	var attributes map[string]interface{}
+	if product.Attributes != "" {
+		json.Unmarshal([]byte(product.Attributes), &attributes)
+	}
+	
 	dto := &ProductDTO{
 		ID:          product.ID.String(),
 		Title:       product.Title,
@@ -82,18 +190,35 @@ func ProductDTOFromModel(product *models.Product) *ProductDTO {
 		Images:      images,
 		Condition:   product.Condition,
 		Category:    product.Category,
+		Attributes:  attributes,
+		ISBN:        product.ISBN,
+		CourseCode:  product.CourseCode,
 		Tags:        tags,
 		Status:      product.Status,
 		SellerID:    product.SellerID.String(),
 		PostedAt:    product.CreatedAt.Format("2006-01-02T15:04:05.000Z"),
 	}
+	if product.CategoryID != nil {
+		dto.CategoryID = product.CategoryID.String()
+	}
+	if product.PublishedAt != nil {
+		dto.PostedAt = product.PublishedAt.Format("2006-01-02T15:04:05.000Z")
+	}
+	if product.PublishAt != nil {
+		dto.PublishAt = product.PublishAt.Format("2006-01-02T15:04:05.000Z")
+	}
+	if product.ExpiresAt != nil {
+		dto.ExpiresAt = product.ExpiresAt.Format("2006-01-02T15:04:05.000Z")
+	}
+	if product.DeletedAt.Valid {
+		dto.DeletedAt = product.DeletedAt.Time.Format("2006-01-02T15:04:05.000Z")
+	}
 	
 	// Include seller information if available
 	if product.Seller.ID != uuid.Nil {
 		dto.Seller = &SellerDTO{
 			ID:         product.Seller.ID.String(),
 			Name:       product.Seller.Name,
-			Email:      product.Seller.Email,
 			Year:       product.Seller.Year,
 			Department: product.Seller.Department,
 			Avatar:     product.Seller.Avatar,
@@ -102,3 +227,149 @@ func ProductDTOFromModel(product *models.Product) *ProductDTO {
 	
 	return dto
 }
+
+// PublicUserDTOFromModel converts a user to the profile anyone may see
+func PublicUserDTOFromModel(user *models.User) PublicUserDTO {
+	dto := PublicUserDTO{
+		ID:         user.ID,
+		Name:       user.Name,
+		Avatar:     user.Avatar,
+		Year:       user.Year,
+		Department: user.Department,
+		CollegeID:  user.CollegeID,
+		CreatedAt:  user.CreatedAt,
+	}
+	if user.College.ID != uuid.Nil {
+		college := user.College
+		dto.College = &college
+	}
+	return dto
+}
+
+// SelfUserDTOFromModel converts a user to the representation of their own account
+func SelfUserDTOFromModel(user *models.User) SelfUserDTO {
+	return SelfUserDTO{
+		PublicUserDTO: PublicUserDTOFromModel(user),
+		Email:         user.Email,
+		IsAdmin:       user.IsAdmin,
+		UpdatedAt:     user.UpdatedAt,
+	}
+}
+
+// AdminUserDTOFromModel converts a user to the admin view of the account
+func AdminUserDTOFromModel(user *models.User) AdminUserDTO {
+	dto := AdminUserDTO{
+		SelfUserDTO: SelfUserDTOFromModel(user),
+		HasPassword: user.Password != "",
+	}
+	if user.DeletedAt.Valid {
+		deletedAt := user.DeletedAt.Time
+		dto.DeletedAt = &deletedAt
+	}
+	dto.HiddenAt = user.HiddenAt
+	dto.SuspendedUntil = user.SuspendedUntil
+	dto.BannedAt = user.BannedAt
+	dto.StatusReason = user.StatusReason
+	return dto
+}
+
+// userDTOFor returns the representation of user the caller may see: their own account, the admin view or the public profile
+func userDTOFor(c *gin.Context, user *models.User) interface{} {
+	if userID, exists := c.Get("userID"); exists && userID == user.ID {
+		return SelfUserDTOFromModel(user)
+	}
+	if isAdminRequest(c) {
+		return AdminUserDTOFromModel(user)
+	}
+	return PublicUserDTOFromModel(user)
+}
+
+// ListingDTOFromModel converts a product for nesting in chats, purchase requests and favorites
+func ListingDTOFromModel(product *models.Product) ListingDTO {
+	dto := ListingDTO{Product: *product}
+	if product.Seller.ID != uuid.Nil {
+		seller := PublicUserDTOFromModel(&product.Seller)
+		dto.Seller = &seller
+	}
+	return dto
+}
+
+// ChatDTOFromModel converts a chat, its listing, participants and messages
+func ChatDTOFromModel(chat *models.Chat) ChatDTO {
+	dto := ChatDTO{
+		Chat:         *chat,
+		Product:      ListingDTOFromModel(&chat.Product),
+		Participants: make([]PublicUserDTO, 0, len(chat.Participants)),
+		Messages:     MessageDTOsFromModels(chat.Messages),
+	}
+	for i := range chat.Participants {
+		dto.Participants = append(dto.Participants, PublicUserDTOFromModel(&chat.Participants[i]))
+	}
+	return dto
+}
+
+// ChatDTOsFromModels converts a list of chats
+func ChatDTOsFromModels(chats []models.Chat) []ChatDTO {
+	dtos := make([]ChatDTO, 0, len(chats))
+	for i := range chats {
+		dtos = append(dtos, ChatDTOFromModel(&chats[i]))
+	}
+	return dtos
+}
+
+// MessageDTOFromModel converts a message and its sender; the text of hidden messages is withheld
+func MessageDTOFromModel(message *models.Message) MessageDTO {
+	dto := MessageDTO{Message: *message, From: PublicUserDTOFromModel(&message.From)}
+	if message.HiddenAt != nil {
+		dto.Text = "This message was hidden by a moderator."
+		dto.Hidden = true
+	}
+	return dto
+}
+
+// MessageDTOsFromModels converts a list of messages
+func MessageDTOsFromModels(messages []models.Message) []MessageDTO {
+	dtos := make([]MessageDTO, 0, len(messages))
+	for i := range messages {
+		dtos = append(dtos, MessageDTOFromModel(&messages[i]))
+	}
+	return dtos
+}
+
+// PurchaseRequestDTOFromModel converts a purchase request, its listing, buyer and seller
+func PurchaseRequestDTOFromModel(request *models.PurchaseRequest) PurchaseRequestDTO {
+	return PurchaseRequestDTO{
+		PurchaseRequest: *request,
+		Product:         ListingDTOFromModel(&request.Product),
+		Buyer:           PublicUserDTOFromModel(&request.Buyer),
+		Seller:          PublicUserDTOFromModel(&request.Seller),
+	}
+}
+
+// PurchaseRequestDTOsFromModels converts a list of purchase requests
+func PurchaseRequestDTOsFromModels(requests []models.PurchaseRequest) []PurchaseRequestDTO {
+	dtos := make([]PurchaseRequestDTO, 0, len(requests))
+	for i := range requests {
+		dtos = append(dtos, PurchaseRequestDTOFromModel(&requests[i]))
+	}
+	return dtos
+}
+
+// FavoriteDTOFromModel converts a favorite; the product is included when it was preloaded
+func FavoriteDTOFromModel(favorite *models.Favorite) FavoriteDTO {
+	dto := FavoriteDTO{Favorite: *favorite}
+	if favorite.Product.ID != uuid.Nil {
+		product := ListingDTOFromModel(&favorite.Product)
+		dto.Product = &product
+	}
+	return dto
+}
+
+// FavoriteDTOsFromModels converts a list of favorites
+func FavoriteDTOsFromModels(favorites []models.Favorite) []FavoriteDTO {
+	dtos := make([]FavoriteDTO, 0, len(favorites))
+	for i := range favorites {
+		dtos = append(dtos, FavoriteDTOFromModel(&favorites[i]))
+	}
+	return dtos
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"marketplace-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const leakedEmail = "private.student@example.edu"
+
+// privateFields must never appear in a response about someone other than the caller
+var privateFields = []string{"email", "is_admin", "isAdmin", "password", "has_password"}
+
+func privateUser(name string) models.User {
+	return models.User{
+		ID:         uuid.New(),
+		Name:       name,
+		Email:      leakedEmail,
+		Password:   "$2a$10$hash",
+		IsAdmin:    true,
+		Year:       "3rd",
+		Department: "CS",
+		College:    models.College{ID: uuid.New(), Name: "Test College", Domain: "example.edu"},
+	}
+}
+
+// assertNoPrivateFields fails when the JSON encoding of v contains an email address or admin flag at any depth
+func assertNoPrivateFields(t *testing.T, name string, v interface{}) {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("%s: marshal: %v", name, err)
+	}
+	if strings.Contains(string(data), leakedEmail) {
+		t.Errorf("%s leaks the email address: %s", name, data)
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("%s: unmarshal: %v", name, err)
+	}
+	var walk func(path string, node interface{})
+	walk = func(path string, node interface{}) {
+		switch node := node.(type) {
+		case map[string]interface{}:
+			for key, value := range node {
+				for _, field := range privateFields {
+					if key == field {
+						t.Errorf("%s exposes %s%s", name, path, key)
+					}
+				}
+				walk(path+key+".", value)
+			}
+		case []interface{}:
+			for _, value := range node {
+				walk(path+"[].", value)
+			}
+		}
+	}
+	walk("", decoded)
+}
+
+func TestPublicResponsesDoNotLeakPrivateFields(t *testing.T) {
+	buyer := privateUser("Buyer")
+	seller := privateUser("Seller")
+	product := models.Product{ID: uuid.New(), Title: "Desk lamp", Images: "[]", Tags: "[]", SellerID: seller.ID, Seller: seller}
+	message := models.Message{ID: uuid.New(), FromID: buyer.ID, From: buyer, Text: "Is this available?"}
+	chat := models.Chat{
+		ID:           uuid.New(),
+		ProductID:    product.ID,
+		Product:      product,
+		Participants: []models.User{buyer, seller},
+		Messages:     []models.Message{message},
+	}
+	request := models.PurchaseRequest{
+		ID: uuid.New(), ProductID: product.ID, Product: product,
+		BuyerID: buyer.ID, Buyer: buyer, SellerID: seller.ID, Seller: seller,
+	}
+	favorite := models.Favorite{ID: uuid.New(), UserID: buyer.ID, User: buyer, ProductID: product.ID, Product: product}
+
+	responses := map[string]interface{}{
+		"public user":      PublicUserDTOFromModel(&seller),
+		"product":          ProductDTOFromModel(&product),
+		"listing":          ListingDTOFromModel(&product),
+		"chat":             ChatDTOFromModel(&chat),
+		"chats":            ChatDTOsFromModels([]models.Chat{chat}),
+		"message":          MessageDTOFromModel(&message),
+		"purchase request": PurchaseRequestDTOFromModel(&request),
+		"favorite":         FavoriteDTOFromModel(&favorite),
+		"collection": CollectionDTO{
+			Products: []ProductDTO{*ProductDTOFromModel(&product)},
+		},
+	}
+	for name, response := range responses {
+		assertNoPrivateFields(t, name, response)
+	}
+}
+
+func TestUserDTOForOnlyShowsOwnAccount(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	user := privateUser("Owner")
+
+	anonymous, _ := gin.CreateTestContext(httptest.NewRecorder())
+	assertNoPrivateFields(t, "profile seen anonymously", userDTOFor(anonymous, &user))
+
+	owner, _ := gin.CreateTestContext(httptest.NewRecorder())
+	owner.Set("userID", user.ID)
+	self, ok := userDTOFor(owner, &user).(SelfUserDTO)
+	if !ok || self.Email != leakedEmail {
+		t.Errorf("owner should get their own account with email, got %#v", userDTOFor(owner, &user))
+	}
+}
@@ -0,0 +1,349 @@
+package handlers
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	"log"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"marketplace-backend/config"
+	"marketplace-backend/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	emailMaxAttempts  = 5
+	emailBatchSize    = 20
+	digestInterval    = 24 * time.Hour
+	digestMaxMessages = 20
+	digestMaxListings = 10
+	emailLayoutHeader = `<!DOCTYPE html><html><body style="font-family:Arial,sans-serif;color:#1f2937;max-width:560px;margin:0 auto;padding:24px">`
+	emailLayoutFooter = `<p style="color:#6b7280;font-size:12px;margin-top:32px">You are receiving this because you opted in to email notifications. <a href="{{.PreferencesURL}}">Manage preferences</a></p></body></html>`
+	emailTextFooter   = "\n--\nYou are receiving this because you opted in to email notifications.\nManage preferences: {{.PreferencesURL}}\n"
+)
+
+// emailSendingTimeout is how long a claimed job may stay "sending" before it is retried
+const emailSendingTimeout = 10 * time.Minute
+
+// emailEventTypes are the notification events that are also sent immediately by email
+var emailEventTypes = map[string]bool{
+	NotificationEventPurchaseRequestCreated:  true,
+	NotificationEventPurchaseRequestAccepted: true,
+	NotificationEventPurchaseRequestDeclined: true,
+	NotificationEventListingExpiring:         true,
+	NotificationEventDataExportReady:         true,
+}
+
+var (
+	eventEmailHTML = htmltemplate.Must(htmltemplate.New("event").Parse(emailLayoutHeader + `
+<p>Hi {{.RecipientName}},</p>
+<h2 style="margin:16px 0 8px">{{.Title}}</h2>
+<p>{{.Body}}</p>
+{{if .URL}}<p><a href="{{.URL}}" style="display:inline-block;background:#4f46e5;color:#fff;padding:10px 16px;border-radius:6px;text-decoration:none">Open marketplace</a></p>{{end}}
+` + emailLayoutFooter))
+
+	eventEmailText = texttemplate.Must(texttemplate.New("event").Parse(`Hi {{.RecipientName}},
+
+{{.Title}}
+
+{{.Body}}
+{{if .URL}}
+Open marketplace: {{.URL}}
+{{end}}` + emailTextFooter))
+
+	digestEmailHTML = htmltemplate.Must(htmltemplate.New("digest").Parse(emailLayoutHeader + `
+<p>Hi {{.RecipientName}}, here is what happened since your last digest.</p>
+{{if .Messages}}<h3>New chat messages</h3>
+<ul>{{range .Messages}}<li><strong>{{.From}}</strong> about <em>{{.Product}}</em>: {{.Text}}</li>{{end}}</ul>
+<p><a href="{{.ChatsURL}}">Reply in your chats</a></p>{{end}}
+{{if .Listings}}<h3>New listings in categories you like</h3>
+<ul>{{range .Listings}}<li><a href="{{.URL}}">{{.Title}}</a> – ${{printf "%.2f" .Price}} ({{.Category}})</li>{{end}}</ul>{{end}}
+` + emailLayoutFooter))
+
+	digestEmailText = texttemplate.Must(texttemplate.New("digest").Parse(`Hi {{.RecipientName}}, here is what happened since your last digest.
+{{if .Messages}}
+New chat messages:
+{{range .Messages}}- {{.From}} about "{{.Product}}": {{.Text}}
+{{end}}Reply in your chats: {{.ChatsURL}}
+{{end}}{{if .Listings}}
+New listings in categories you like:
+{{range .Listings}}- {{.Title}} – ${{printf "%.2f" .Price}} ({{.Category}}) {{.URL}}
+{{end}}{{end}}` + emailTextFooter))
+)
+
+type eventEmailData struct {
+	RecipientName  string
+	Title          string
+	Body           string
+	URL            string
+	PreferencesURL string
+}
+
+type digestMessage struct {
+	From    string
+	Product string
+	Text    string
+}
+
+type digestListing struct {
+	Title    string
+	Price    float64
+	Category string
+	URL      string
+}
+
+type digestEmailData struct {
+	RecipientName  string
+	Messages       []digestMessage
+	Listings       []digestListing
+	ChatsURL       string
+	PreferencesURL string
+}
+
+// renderEmail executes the HTML and text templates with the same data
+func renderEmail(html *htmltemplate.Template, text *texttemplate.Template, data interface{}) (string, string, error) {
+	var htmlBody, textBody bytes.Buffer
+	if err := html.Execute(&htmlBody, data); err != nil {
+		return "", "", err
+	}
+	if err := text.Execute(&textBody, data); err != nil {
+		return "", "", err
+	}
+	return htmlBody.String(), textBody.String(), nil
+}
+
+// enqueueEmail stores a rendered email in the outbound queue
+func enqueueEmail(user models.User, subject, htmlBody, textBody string) error {
+	job := models.EmailJob{
+		UserID:        user.ID,
+		ToEmail:       user.Email,
+		Subject:       subject,
+		HTMLBody:      htmlBody,
+		TextBody:      textBody,
+		Status:        "pending",
+		NextAttemptAt: time.Now(),
+	}
+	return config.DB.Create(&job).Error
+}
+
+// enqueueNotificationEmail renders and queues the immediate email for a notification event
+func enqueueNotificationEmail(event NotificationEvent) {
+	var user models.User
+	if err := config.DB.First(&user, event.UserID).Error; err != nil || user.Email == "" {
+		return
+	}
+
+	data := eventEmailData{
+		RecipientName:  user.Name,
+		Title:          event.Title,
+		Body:           event.Body,
+		PreferencesURL: config.GetFrontendURL() + "/profile",
+	}
+	if event.Link != "" {
+		data.URL = config.GetFrontendURL() + event.Link
+	}
+
+	htmlBody, textBody, err := renderEmail(eventEmailHTML, eventEmailText, data)
+	if err != nil {
+		log.Printf("Failed to render %s email: %v", event.Type, err)
+		return
+	}
+
+	if err := enqueueEmail(user, event.Title, htmlBody, textBody); err != nil {
+		log.Printf("Failed to queue %s email for user %s: %v", event.Type, user.ID, err)
+	}
+}
+
+// processEmailQueue delivers due emails, rescheduling failures with exponential backoff
+func processEmailQueue() {
+	if config.Mailer == nil {
+		return
+	}
+
+	// Jobs left in "sending" by a worker that died mid-batch go back to the queue
+	err := config.DB.Model(&models.EmailJob{}).
+		Where("status = ? AND updated_at < ?", "sending", time.Now().Add(-emailSendingTimeout)).
+		Update("status", "pending").Error
+	if err != nil {
+		log.Printf("Failed to requeue stalled emails: %v", err)
+	}
+
+	jobs, err := claimEmailJobs()
+	if err != nil {
+		log.Printf("Failed to process email queue: %v", err)
+		return
+	}
+
+	// Sending happens outside any transaction so a slow SMTP server holds no locks, and each result is
+	// recorded on its own so a failure can't cause delivered emails to be sent again
+	for _, job := range jobs {
+		updates := map[string]interface{}{"attempts": job.Attempts + 1}
+
+		sendErr := config.Mailer.Send(config.EmailMessage{
+			To:       job.ToEmail,
+			Subject:  job.Subject,
+			HTMLBody: job.HTMLBody,
+			TextBody: job.TextBody,
+		})
+		if sendErr == nil {
+			updates["status"] = "sent"
+			updates["sent_at"] = time.Now()
+			updates["last_error"] = ""
+		} else {
+			log.Printf("Email %s to %s failed (attempt %d): %v", job.ID, job.ToEmail, job.Attempts+1, sendErr)
+			updates["last_error"] = sendErr.Error()
+			if job.Attempts+1 >= emailMaxAttempts {
+				updates["status"] = "failed"
+			} else {
+				updates["status"] = "pending"
+				updates["next_attempt_at"] = time.Now().Add(emailRetryDelay(job.Attempts + 1))
+			}
+		}
+
+		if err := config.DB.Model(&models.EmailJob{}).Where("id = ?", job.ID).Updates(updates).Error; err != nil {
+			log.Printf("Failed to record result of email %s: %v", job.ID, err)
+		}
+	}
+}
+
+// claimEmailJobs marks a batch of due jobs as "sending" in a short transaction so no other worker picks them up
+func claimEmailJobs() ([]models.EmailJob, error) {
+	var jobs []models.EmailJob
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", "pending", time.Now()).
+			Order("next_attempt_at ASC").
+			Limit(emailBatchSize).
+			Find(&jobs).Error
+		if err != nil || len(jobs) == 0 {
+			return err
+		}
+
+		ids := make([]uuid.UUID, 0, len(jobs))
+		for _, job := range jobs {
+			ids = append(ids, job.ID)
+		}
+		return tx.Model(&models.EmailJob{}).Where("id IN ?", ids).Update("status", "sending").Error
+	})
+	return jobs, err
+}
+
+// emailRetryDelay doubles the wait after every failed attempt: 1m, 2m, 4m, ...
+func emailRetryDelay(attempts int) time.Duration {
+	return time.Minute << uint(attempts-1)
+}
+
+// sendDailyDigests queues a digest for every opted-in user whose last digest is older than a day
+func sendDailyDigests() {
+	var prefs []models.NotificationPreference
+	if err := config.DB.Where("event_type = ? AND email = ?", NotificationEventDailyDigest, true).Find(&prefs).Error; err != nil {
+		log.Printf("Failed to load digest subscribers: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for _, pref := range prefs {
+		cursor := models.DigestCursor{UserID: pref.UserID, LastSentAt: now.Add(-digestInterval)}
+		config.DB.Where("user_id = ?", pref.UserID).First(&cursor)
+		if now.Sub(cursor.LastSentAt) < digestInterval {
+			continue
+		}
+
+		if err := queueDigestFor(pref.UserID, cursor.LastSentAt); err != nil {
+			log.Printf("Failed to build digest for user %s: %v", pref.UserID, err)
+			continue
+		}
+
+		cursor.LastSentAt = now
+		config.DB.Save(&cursor)
+	}
+}
+
+// queueDigestFor builds the digest of activity since the given time (the last digest); empty digests are skipped.
+// Messages are those received since then whether or not they were read, as chats don't track read state.
+// Messages hidden by moderation and anything from users blocked either way are left out, and listings are
+// filtered like the browsing pages.
+func queueDigestFor(userID uuid.UUID, since time.Time) error {
+	var user models.User
+	if err := config.DB.First(&user, userID).Error; err != nil {
+		return err
+	}
+	if user.Email == "" {
+		return nil
+	}
+
+	var messages []models.Message
+	err := config.DB.Preload("From", withDeleted).Preload("Chat.Product", withDeleted).
+		Joins("JOIN chat_participants ON chat_participants.chat_id = messages.chat_id").
+		Where("chat_participants.user_id = ? AND messages.from_id <> ? AND messages.created_at > ?", userID, userID, since).
+		Where("messages.hidden_at IS NULL").
+		Where("messages.from_id NOT IN (SELECT blocked_id FROM blocks WHERE blocker_id = ?)", userID).
+		Where("messages.from_id NOT IN (SELECT blocker_id FROM blocks WHERE blocked_id = ?)", userID).
+		Order("messages.created_at ASC").
+		Limit(digestMaxMessages).
+		Find(&messages).Error
+	if err != nil {
+		return err
+	}
+
+	var categories []string
+	err = config.DB.Model(&models.Favorite{}).
+		Joins("JOIN products ON products.id = favorites.product_id AND products.deleted_at IS NULL").
+		Where("favorites.user_id = ?", userID).
+		Distinct().
+		Pluck("products.category", &categories).Error
+	if err != nil {
+		return err
+	}
+
+	var products []models.Product
+	if len(categories) > 0 {
+		err = config.DB.Scopes(visibleListingsTo(userID)).
+			Where("category IN ? AND college_id = ? AND seller_id <> ? AND status = ? AND published_at > ?",
+				categories, user.CollegeID, userID, ProductStatusAvailable, since).
+			Order("published_at DESC").
+			Limit(digestMaxListings).
+			Find(&products).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(messages) == 0 && len(products) == 0 {
+		return nil
+	}
+
+	frontendURL := config.GetFrontendURL()
+	data := digestEmailData{
+		RecipientName:  user.Name,
+		ChatsURL:       frontendURL + "/chats",
+		PreferencesURL: frontendURL + "/profile",
+	}
+	for _, m := range messages {
+		data.Messages = append(data.Messages, digestMessage{
+			From:    m.From.Name,
+			Product: m.Chat.Product.Title,
+			Text:    truncateText(strings.TrimSpace(m.Text), 200),
+		})
+	}
+	for _, p := range products {
+		data.Listings = append(data.Listings, digestListing{
+			Title:    p.Title,
+			Price:    p.Price,
+			Category: p.Category,
+			URL:      frontendURL + "/product/" + p.ID.String(),
+		})
+	}
+
+	htmlBody, textBody, err := renderEmail(digestEmailHTML, digestEmailText, data)
+	if err != nil {
+		return err
+	}
+
+	return enqueueEmail(user, "Your daily marketplace digest", htmlBody, textBody)
+}
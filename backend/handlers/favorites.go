@@ -1,41 +1,48 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"marketplace-backend/config"
 	"marketplace-backend/models"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
-// GetFavorites returns all favorites for a user
+// GetFavorites returns all favorites for the authenticated user
 func GetFavorites(c *gin.Context) {
-	userID := c.Query("user_id")
-	if userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id query parameter required"})
-		return
-	}
-
-	userUUID, err := uuid.Parse(userID)
-	if err != nil {
-		// Handle legacy string IDs from frontend - return empty array
-		c.JSON(http.StatusOK, []models.Favorite{})
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
 		return
 	}
 
 	var favorites []models.Favorite
-	result := config.DB.Preload("Product.Seller").Where("user_id = ?", userUUID).Find(&favorites)
+	result := config.DB.Preload("Product.Seller").
+		Joins("JOIN products ON products.id = favorites.product_id AND products.deleted_at IS NULL").
+		Where("favorites.user_id = ?", userID).
+		Find(&favorites)
 	if result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch favorites"})
 		return
 	}
 
-	c.JSON(http.StatusOK, favorites)
+	c.JSON(http.StatusOK, FavoriteDTOsFromModels(favorites))
 }
 
-// CreateFavorite adds a product to user's favorites
+// CreateFavorite adds a product to the authenticated user's favorites,
+// optionally placing it in some of their collections
 func CreateFavorite(c *gin.Context) {
+	userIDValue, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	userID := userIDValue.(uuid.UUID)
+
 	productID := c.Param("id")
 	productUUID, err := uuid.Parse(productID)
 	if err != nil {
@@ -44,56 +51,91 @@ func CreateFavorite(c *gin.Context) {
 	}
 
 	var requestData struct {
-		UserID uuid.UUID `json:"user_id" binding:"required"`
+		CollectionIDs []uuid.UUID `json:"collection_ids"`
 	}
-	if err := c.ShouldBindJSON(&requestData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&requestData); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	var product models.Product
+	if err := config.DB.Preload("Seller").First(&product, productUUID).Error; err != nil || !productVisibleTo(c, &product) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
 		return
 	}
 
-	// Check if already favorited
+	// Check if already favorited; re-favoriting with collections just files it there
 	var existing models.Favorite
-	if config.DB.Where("user_id = ? AND product_id = ?", requestData.UserID, productUUID).First(&existing).Error == nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "Product already favorited"})
+	if config.DB.Where("user_id = ? AND product_id = ?", userID, productUUID).First(&existing).Error == nil {
+		if len(requestData.CollectionIDs) == 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "Product already favorited"})
+			return
+		}
+		if err := addToCollections(config.DB, userID, productUUID, requestData.CollectionIDs); err != nil {
+			if errors.Is(err, errCollectionsNotFound) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add favorite to collections"})
+			}
+			return
+		}
+		c.JSON(http.StatusOK, FavoriteDTOFromModel(&existing))
 		return
 	}
 
 	favorite := models.Favorite{
-		UserID:    requestData.UserID,
-		ProductID: productUUID,
+		UserID:             userID,
+		ProductID:          productUUID,
+		PriceWhenFavorited: product.Price,
 	}
 
-	result := config.DB.Create(&favorite)
-	if result.Error != nil {
+	// The favorite is only kept if it could be filed in every requested collection
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&favorite).Error; err != nil {
+			return err
+		}
+		return addToCollections(tx, userID, productUUID, requestData.CollectionIDs)
+	})
+	if errors.Is(err, errCollectionsNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create favorite"})
 		return
 	}
 
-	c.JSON(http.StatusCreated, favorite)
+	if product.SellerID != userID {
+		notify(NotificationEvent{
+			UserID: product.SellerID,
+			Type:   NotificationEventFavoriteCreated,
+			Title:  "Someone saved your listing",
+			Body:   fmt.Sprintf("%s was added to a favorites list", product.Title),
+			Link:   "/product/" + product.ID.String(),
+		})
+	}
+
+	c.JSON(http.StatusCreated, FavoriteDTOFromModel(&favorite))
 }
 
-// DeleteFavorite removes a product from user's favorites
+// DeleteFavorite removes a product from the authenticated user's favorites and collections
 func DeleteFavorite(c *gin.Context) {
-	productID := c.Param("id")
-	productUUID, err := uuid.Parse(productID)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
 		return
 	}
 
-	userID := c.Query("user_id")
-	if userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id query parameter required"})
-		return
-	}
-
-	userUUID, err := uuid.Parse(userID)
+	productID := c.Param("id")
+	productUUID, err := uuid.Parse(productID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
 		return
 	}
 
-	result := config.DB.Where("user_id = ? AND product_id = ?", userUUID, productUUID).Delete(&models.Favorite{})
+	result := config.DB.Where("user_id = ? AND product_id = ?", userID, productUUID).Delete(&models.Favorite{})
 	if result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove favorite"})
 		return
@@ -104,5 +146,9 @@ func DeleteFavorite(c *gin.Context) {
 		return
 	}
 
+	config.DB.Where("product_id = ? AND collection_id IN (?)", productUUID,
+		config.DB.Model(&models.WishlistCollection{}).Select("id").Where("user_id = ?", userID)).
+		Delete(&models.CollectionItem{})
+
 	c.JSON(http.StatusOK, gin.H{"message": "Favorite removed successfully"})
 }
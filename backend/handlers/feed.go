@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"marketplace-backend/config"
+	"marketplace-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultFeedPageSize = 20
+	maxFeedPageSize     = 50
+)
+
+// Why a listing is in the feed
+const (
+	FeedReasonFollowedSeller    = "followed_seller"
+	FeedReasonFavoritedCategory = "favorited_category"
+	FeedReasonSavedTag          = "saved_tag"
+)
+
+// FeedItemDTO is a listing in the feed with the reasons it was picked
+type FeedItemDTO struct {
+	ProductDTO
+	Reasons []string `json:"reasons"`
+}
+
+// FeedResponse is one page of the feed; pass NextCursor as ?cursor= for the next page
+type FeedResponse struct {
+	Items      []FeedItemDTO `json:"items"`
+	NextCursor string        `json:"nextCursor,omitempty"`
+}
+
+// feedInterests are what the feed matches listings against, derived from follows and favorites
+type feedInterests struct {
+	sellerIDs   []uuid.UUID
+	categoryIDs []uuid.UUID
+	tagIDs      []uuid.UUID
+}
+
+// GetFeed returns available listings from followed sellers, from categories of the caller's favorites
+// and carrying tags of their favorites, newest first (?limit=&cursor=). The caller's own listings and
+// listings they already favorited are left out.
+func GetFeed(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultFeedPageSize)))
+	if err != nil || limit < 1 || limit > maxFeedPageSize {
+		limit = defaultFeedPageSize
+	}
+
+	interests, err := loadFeedInterests(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load feed"})
+		return
+	}
+
+	var conditions []string
+	var args []interface{}
+	if len(interests.sellerIDs) > 0 {
+		conditions = append(conditions, "products.seller_id IN ?")
+		args = append(args, interests.sellerIDs)
+	}
+	if len(interests.categoryIDs) > 0 {
+		conditions = append(conditions, "products.category_id IN ?")
+		args = append(args, interests.categoryIDs)
+	}
+	if len(interests.tagIDs) > 0 {
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM product_tags WHERE product_tags.product_id = products.id AND product_tags.tag_id IN ?)")
+		args = append(args, interests.tagIDs)
+	}
+	if len(conditions) == 0 {
+		c.JSON(http.StatusOK, FeedResponse{Items: []FeedItemDTO{}})
+		return
+	}
+
+	query := config.DB.Preload("Seller").Scopes(visibleListings(c)).
+		Where("products.status = ? AND products.seller_id <> ?", ProductStatusAvailable, userID).
+		Where("("+strings.Join(conditions, " OR ")+")", args...).
+		Where("NOT EXISTS (SELECT 1 FROM favorites WHERE favorites.product_id = products.id AND favorites.user_id = ?)", userID)
+	if cursor := c.Query("cursor"); cursor != "" {
+		at, id, err := decodeFeedCursor(cursor)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+		query = query.Where("(COALESCE(products.published_at, products.created_at), products.id) < (?, ?)", at, id)
+	}
+
+	// One extra row tells whether there is another page
+	var products []models.Product
+	err = query.Order("COALESCE(products.published_at, products.created_at) DESC, products.id DESC").
+		Limit(limit + 1).
+		Find(&products).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load feed"})
+		return
+	}
+
+	response := FeedResponse{Items: make([]FeedItemDTO, 0, len(products))}
+	if len(products) > limit {
+		products = products[:limit]
+		last := products[limit-1]
+		at := last.CreatedAt
+		if last.PublishedAt != nil {
+			at = *last.PublishedAt
+		}
+		response.NextCursor = encodeFeedCursor(at, last.ID)
+	}
+
+	taggedIDs, err := feedTaggedProducts(products, interests.tagIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load feed"})
+		return
+	}
+	for i := range products {
+		product := &products[i]
+		item := FeedItemDTO{ProductDTO: *ProductDTOFromModel(product), Reasons: []string{}}
+		if containsUUID(interests.sellerIDs, product.SellerID) {
+			item.Reasons = append(item.Reasons, FeedReasonFollowedSeller)
+		}
+		if product.CategoryID != nil && containsUUID(interests.categoryIDs, *product.CategoryID) {
+			item.Reasons = append(item.Reasons, FeedReasonFavoritedCategory)
+		}
+		if taggedIDs[product.ID] {
+			item.Reasons = append(item.Reasons, FeedReasonSavedTag)
+		}
+		response.Items = append(response.Items, item)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func loadFeedInterests(userID interface{}) (feedInterests, error) {
+	var interests feedInterests
+	err := config.DB.Model(&models.Follow{}).Where("follower_id = ?", userID).Pluck("seller_id", &interests.sellerIDs).Error
+	if err != nil {
+		return interests, err
+	}
+
+	err = config.DB.Table("favorites").
+		Joins("JOIN products ON products.id = favorites.product_id").
+		Where("favorites.user_id = ? AND products.category_id IS NOT NULL", userID).
+		Distinct().
+		Pluck("products.category_id", &interests.categoryIDs).Error
+	if err != nil {
+		return interests, err
+	}
+
+	err = config.DB.Table("product_tags").
+		Joins("JOIN favorites ON favorites.product_id = product_tags.product_id").
+		Where("favorites.user_id = ?", userID).
+		Distinct().
+		Pluck("product_tags.tag_id", &interests.tagIDs).Error
+	return interests, err
+}
+
+// feedTaggedProducts returns which of the products carry one of the saved tags
+func feedTaggedProducts(products []models.Product, tagIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	tagged := map[uuid.UUID]bool{}
+	if len(products) == 0 || len(tagIDs) == 0 {
+		return tagged, nil
+	}
+
+	productIDs := make([]uuid.UUID, 0, len(products))
+	for _, product := range products {
+		productIDs = append(productIDs, product.ID)
+	}
+	var matches []uuid.UUID
+	err := config.DB.Table("product_tags").
+		Where("product_id IN ? AND tag_id IN ?", productIDs, tagIDs).
+		Distinct().
+		Pluck("product_id", &matches).Error
+	for _, id := range matches {
+		tagged[id] = true
+	}
+	return tagged, err
+}
+
+func containsUUID(ids []uuid.UUID, id uuid.UUID) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeFeedCursor points just past the last listing of a page
+func encodeFeedCursor(at time.Time, id uuid.UUID) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(at.UTC().Format(time.RFC3339Nano) + "|" + id.String()))
+}
+
+func decodeFeedCursor(cursor string) (time.Time, uuid.UUID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, uuid.Nil, err
+	}
+	atPart, idPart, found := strings.Cut(string(raw), "|")
+	if !found {
+		return time.Time{}, uuid.Nil, fmt.Errorf("malformed cursor")
+	}
+	at, err := time.Parse(time.RFC3339Nano, atPart)
+	if err != nil {
+		return time.Time{}, uuid.Nil, err
+	}
+	id, err := uuid.Parse(idPart)
+	return at, id, err
+}
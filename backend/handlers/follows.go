@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"net/http"
+
+	"marketplace-backend/config"
+	"marketplace-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// FollowSeller adds the seller's new listings to the caller's feed
+func FollowSeller(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	sellerID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+	if sellerID == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot follow yourself"})
+		return
+	}
+
+	var seller models.User
+	if err := config.DB.First(&seller, sellerID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if usersBlocked(userID.(uuid.UUID), sellerID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot follow this user"})
+		return
+	}
+
+	var follow models.Follow
+	if config.DB.Where("follower_id = ? AND seller_id = ?", userID, sellerID).First(&follow).Error == nil {
+		c.JSON(http.StatusOK, follow)
+		return
+	}
+
+	follow = models.Follow{FollowerID: userID.(uuid.UUID), SellerID: sellerID}
+	if err := config.DB.Create(&follow).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to follow seller"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, follow)
+}
+
+// UnfollowSeller removes the seller from the caller's feed
+func UnfollowSeller(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	sellerID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	result := config.DB.Where("follower_id = ? AND seller_id = ?", userID, sellerID).Delete(&models.Follow{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unfollow seller"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "You are not following this seller"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Seller unfollowed"})
+}
+
+// GetFollowing returns the public profiles of the sellers the caller follows, most recently followed first
+func GetFollowing(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var sellers []models.User
+	err := config.DB.Preload("College").
+		Joins("JOIN follows ON follows.seller_id = users.id").
+		Where("follows.follower_id = ?", userID).
+		Order("follows.created_at DESC").
+		Find(&sellers).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch followed sellers"})
+		return
+	}
+
+	profiles := make([]PublicUserDTO, 0, len(sellers))
+	for i := range sellers {
+		profiles = append(profiles, PublicUserDTOFromModel(&sellers[i]))
+	}
+	c.JSON(http.StatusOK, profiles)
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"log"
+	"time"
+)
+
+// backgroundJob is a task run periodically for the lifetime of the server
+type backgroundJob struct {
+	name     string
+	interval time.Duration
+	run      func()
+}
+
+var backgroundJobs = []backgroundJob{
+	{name: "email-queue", interval: 30 * time.Second, run: processEmailQueue},
+	{name: "daily-digest", interval: time.Hour, run: sendDailyDigests},
+	{name: "push-subscription-pruning", interval: 6 * time.Hour, run: pruneExpiredPushSubscriptions},
+	{name: "scheduled-publishing", interval: time.Minute, run: publishScheduledListings},
+	{name: "listing-expiry", interval: 15 * time.Minute, run: expireListings},
+	{name: "sold-listing-archival", interval: 6 * time.Hour, run: archiveSoldListings},
+	{name: "deleted-listing-cleanup", interval: 6 * time.Hour, run: purgeDeletedListingFavorites},
+	{name: "stalled-import-cleanup", interval: 10 * time.Minute, run: failStalledImports},
+	{name: "data-export-expiry", interval: time.Hour, run: expireDataExports},
+	{name: "deleted-account-purge", interval: 6 * time.Hour, run: purgeDeletedAccounts},
+}
+
+// StartBackgroundJobs launches every periodic job in its own goroutine
+func StartBackgroundJobs() {
+	for _, job := range backgroundJobs {
+		go runPeriodically(job)
+	}
+}
+
+func runPeriodically(job backgroundJob) {
+	log.Printf("Starting background job %s (every %s)", job.name, job.interval)
+	ticker := time.NewTicker(job.interval)
+	defer ticker.Stop()
+
+	for {
+		runJobSafely(job)
+		<-ticker.C
+	}
+}
+
+// runJobSafely keeps a panicking job from taking the server down
+func runJobSafely(job backgroundJob) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Background job %s panicked: %v", job.name, r)
+		}
+	}()
+	job.run()
+}
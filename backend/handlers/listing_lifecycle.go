@@ -0,0 +1,302 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"time"
+
+	"marketplace-backend/config"
+	"marketplace-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Product statuses
+const (
+	ProductStatusDraft     = "draft"
+	ProductStatusScheduled = "scheduled"
+	ProductStatusAvailable = "available"
+	ProductStatusRequested = "requested"
+	ProductStatusSold      = "sold"
+	ProductStatusExpired   = "expired"
+	ProductStatusArchived  = "archived"
+)
+
+// publicProductStatuses are the statuses shown when browsing listings
+var publicProductStatuses = []string{ProductStatusAvailable, ProductStatusRequested, ProductStatusSold}
+
+// lifecycleProductStatuses can only be reached through the publish/relist endpoints and jobs
+var lifecycleProductStatuses = map[string]bool{
+	ProductStatusDraft:     true,
+	ProductStatusScheduled: true,
+	ProductStatusExpired:   true,
+	ProductStatusArchived:  true,
+}
+
+// PublishProductRequest publishes a draft now, or schedules it when PublishAt is in the future
+type PublishProductRequest struct {
+	PublishAt *time.Time `json:"publish_at"`
+}
+
+// GetMyListings returns the caller's listings in every status, optionally filtered by ?status=
+// (status=deleted lists removed listings that can still be restored)
+func GetMyListings(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	query := config.DB.Preload("Seller").Where("seller_id = ?", userID)
+	switch status := c.Query("status"); status {
+	case "":
+	case "deleted":
+		// Deleted listings are still restorable within the restore window
+		cutoff := time.Now().AddDate(0, 0, -config.ProductRestoreWindowDays())
+		query = query.Unscoped().Where("deleted_at > ?", cutoff)
+	default:
+		query = query.Where("status = ?", status)
+	}
+
+	var products []models.Product
+	if err := query.Order("created_at DESC").Find(&products).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch listings"})
+		return
+	}
+
+	productDTOs := make([]ProductDTO, 0, len(products))
+	for i := range products {
+		productDTOs = append(productDTOs, *ProductDTOFromModel(&products[i]))
+	}
+
+	c.JSON(http.StatusOK, productDTOs)
+}
+
+// PublishProduct takes a draft or scheduled listing live, or (re)schedules it
+func PublishProduct(c *gin.Context) {
+	product, ok := loadOwnProduct(c)
+	if !ok {
+		return
+	}
+
+	if product.Status != ProductStatusDraft && product.Status != ProductStatusScheduled {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only drafts and scheduled listings can be published"})
+		return
+	}
+
+	var req PublishProductRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if req.PublishAt != nil && req.PublishAt.After(time.Now()) {
+		err := config.DB.Model(product).Updates(map[string]interface{}{
+			"status":     ProductStatusScheduled,
+			"publish_at": *req.PublishAt,
+		}).Error
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to schedule listing"})
+			return
+		}
+	} else if err := publishListing(config.DB, product); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to publish listing"})
+		return
+	}
+
+	config.DB.Preload("Seller").First(product, product.ID)
+	c.JSON(http.StatusOK, ProductDTOFromModel(product))
+}
+
+// RelistProduct brings an expired listing back, or bumps a live one to the top once the cooldown has passed
+func RelistProduct(c *gin.Context) {
+	product, ok := loadOwnProduct(c)
+	if !ok {
+		return
+	}
+
+	switch product.Status {
+	case ProductStatusExpired:
+	case ProductStatusAvailable:
+		if product.PublishedAt != nil {
+			if wait := time.Until(product.PublishedAt.Add(config.ListingBumpCooldown())); wait > 0 {
+				c.Header("Retry-After", fmt.Sprint(int(math.Ceil(wait.Seconds()))))
+				c.JSON(http.StatusTooManyRequests, gin.H{
+					"error":        "This listing was bumped recently",
+					"next_bump_at": product.PublishedAt.Add(config.ListingBumpCooldown()),
+				})
+				return
+			}
+		}
+	default:
+		c.JSON(http.StatusConflict, gin.H{"error": "Only live or expired listings can be relisted"})
+		return
+	}
+
+	if err := publishListing(config.DB, product); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to relist listing"})
+		return
+	}
+
+	config.DB.Preload("Seller").First(product, product.ID)
+	c.JSON(http.StatusOK, ProductDTOFromModel(product))
+}
+
+// publishListing makes a listing live with a fresh expiry; first-time publishes alert saved searches
+func publishListing(db *gorm.DB, product *models.Product) error {
+	firstPublish := product.PublishedAt == nil
+	now := time.Now()
+	expiresAt := now.AddDate(0, 0, config.ListingExpiryDays())
+
+	err := db.Model(product).Updates(map[string]interface{}{
+		"status":                  ProductStatusAvailable,
+		"publish_at":              nil,
+		"published_at":            now,
+		"expires_at":              expiresAt,
+		"expiry_reminder_sent_at": nil,
+	}).Error
+	if err != nil {
+		return err
+	}
+	product.Status = ProductStatusAvailable
+	product.PublishAt = nil
+	product.PublishedAt = &now
+	product.ExpiresAt = &expiresAt
+	product.ExpiryReminderSentAt = nil
+
+	if firstPublish {
+		go matchSavedSearches(*product)
+	}
+	return nil
+}
+
+// publishScheduledListings publishes scheduled listings whose time has come
+func publishScheduledListings() {
+	var products []models.Product
+	if err := config.DB.Where("status = ? AND publish_at <= ?", ProductStatusScheduled, time.Now()).Find(&products).Error; err != nil {
+		log.Printf("Failed to load scheduled listings: %v", err)
+		return
+	}
+
+	for i := range products {
+		product := &products[i]
+		if err := publishListing(config.DB, product); err != nil {
+			log.Printf("Failed to publish scheduled listing %s: %v", product.ID, err)
+			continue
+		}
+		notify(NotificationEvent{
+			UserID: product.SellerID,
+			Type:   NotificationEventListingPublished,
+			Title:  "Your listing is live",
+			Body:   fmt.Sprintf("%s is now visible to buyers", product.Title),
+			Link:   "/product/" + product.ID.String(),
+		})
+	}
+}
+
+// expireListings reminds sellers of listings about to expire and expires the ones past their date
+func expireListings() {
+	now := time.Now()
+
+	var expiring []models.Product
+	err := config.DB.
+		Where("status = ? AND expiry_reminder_sent_at IS NULL AND expires_at > ? AND expires_at <= ?",
+			ProductStatusAvailable, now, now.AddDate(0, 0, config.ListingExpiryReminderDays())).
+		Find(&expiring).Error
+	if err != nil {
+		log.Printf("Failed to load expiring listings: %v", err)
+	}
+	for _, product := range expiring {
+		notify(NotificationEvent{
+			UserID: product.SellerID,
+			Type:   NotificationEventListingExpiring,
+			Title:  "Your listing expires soon",
+			Body:   fmt.Sprintf("%s expires on %s. Relist it to keep it visible.", product.Title, product.ExpiresAt.Format("Jan 2")),
+			Link:   "/product/" + product.ID.String(),
+		})
+		config.DB.Model(&product).UpdateColumn("expiry_reminder_sent_at", now)
+	}
+
+	var expired []models.Product
+	if err := config.DB.Where("status = ? AND expires_at <= ?", ProductStatusAvailable, now).Find(&expired).Error; err != nil {
+		log.Printf("Failed to load expired listings: %v", err)
+		return
+	}
+	for _, product := range expired {
+		if err := config.DB.Model(&product).Update("status", ProductStatusExpired).Error; err != nil {
+			log.Printf("Failed to expire listing %s: %v", product.ID, err)
+			continue
+		}
+		notify(NotificationEvent{
+			UserID: product.SellerID,
+			Type:   NotificationEventListingExpired,
+			Title:  "Your listing expired",
+			Body:   fmt.Sprintf("%s is no longer visible to buyers. Relist it any time.", product.Title),
+			Link:   "/product/" + product.ID.String(),
+		})
+	}
+}
+
+// archiveSoldListings hides sold listings from browsing once the retention period has passed
+func archiveSoldListings() {
+	cutoff := time.Now().AddDate(0, 0, -config.SoldListingRetentionDays())
+	result := config.DB.Model(&models.Product{}).
+		Where("status = ? AND sold_at <= ?", ProductStatusSold, cutoff).
+		Update("status", ProductStatusArchived)
+	if result.Error != nil {
+		log.Printf("Failed to archive sold listings: %v", result.Error)
+		return
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("Archived %d sold listings", result.RowsAffected)
+	}
+}
+
+// productVisibleTo reports whether a listing can be viewed. Drafts and scheduled listings are private to the
+// seller, listings hidden by moderation or of suspended and banned sellers are only shown to the seller and
+// admins, and blocked users can't see each other's listings. The product's Seller must be preloaded.
+func productVisibleTo(c *gin.Context, product *models.Product) bool {
+	userID, exists := c.Get("userID")
+	if exists && userID == product.SellerID {
+		return true
+	}
+	if product.Status == ProductStatusDraft || product.Status == ProductStatusScheduled {
+		return false
+	}
+	if product.HiddenAt != nil || sellerHidden(&product.Seller) {
+		return isAdminRequest(c)
+	}
+	return !exists || !usersBlocked(userID.(uuid.UUID), product.SellerID)
+}
+
+func loadOwnProduct(c *gin.Context) (*models.Product, bool) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return nil, false
+	}
+
+	productID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return nil, false
+	}
+
+	var product models.Product
+	if err := config.DB.First(&product, productID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return nil, false
+	}
+	if product.SellerID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only manage your own products"})
+		return nil, false
+	}
+
+	return &product, true
+}
@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"marketplace-backend/config"
+	"marketplace-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultStatsDays = 30
+	maxStatsDays     = 90
+	statsDateFormat  = "2006-01-02"
+)
+
+// ListingStatsPoint is one day of activity on a listing
+type ListingStatsPoint struct {
+	Date      string `json:"date"`
+	Views     int64  `json:"views"`
+	Favorites int64  `json:"favorites"`
+	Requests  int64  `json:"requests"`
+}
+
+// ListingStats summarizes activity on one of the seller's listings
+type ListingStats struct {
+	ProductID      string              `json:"productId"`
+	Title          string              `json:"title"`
+	Price          float64             `json:"price"`
+	Status         string              `json:"status"`
+	PostedAt       string              `json:"postedAt"`
+	TotalViews     int64               `json:"totalViews"`
+	TotalFavorites int64               `json:"totalFavorites"`
+	TotalRequests  int64               `json:"totalRequests"`
+	Series         []ListingStatsPoint `json:"series"`
+}
+
+// recordProductView counts a product page view, at most once per viewer per day.
+// Sellers looking at their own listing are not counted.
+func recordProductView(c *gin.Context, product *models.Product) {
+	viewerKey := ""
+	if userID, exists := c.Get("userID"); exists {
+		if userID == product.SellerID {
+			return
+		}
+		viewerKey = userID.(uuid.UUID).String()
+	} else {
+		sum := sha256.Sum256([]byte(c.ClientIP() + "|" + c.Request.UserAgent()))
+		viewerKey = "anon:" + hex.EncodeToString(sum[:16])
+	}
+
+	view := models.ProductView{
+		ProductID: product.ID,
+		ViewerKey: viewerKey,
+		Day:       time.Now().UTC().Truncate(24 * time.Hour),
+	}
+	if err := config.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&view).Error; err != nil {
+		log.Printf("Failed to record view for product %s: %v", product.ID, err)
+	}
+}
+
+// GetMyListingStats returns per-listing views, favorites and requests with a daily time series
+func GetMyListingStats(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", strconv.Itoa(defaultStatsDays)))
+	if err != nil || days < 1 || days > maxStatsDays {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and " + strconv.Itoa(maxStatsDays)})
+		return
+	}
+
+	var products []models.Product
+	if err := config.DB.Where("seller_id = ?", userID).Order("created_at DESC").Find(&products).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch listings"})
+		return
+	}
+	if len(products) == 0 {
+		c.JSON(http.StatusOK, []ListingStats{})
+		return
+	}
+
+	stats, err := buildListingStats(products, days)
+	if err != nil {
+		log.Printf("Failed to compute listing stats: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute listing stats"})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// buildListingStats assembles totals and a zero-filled daily series for the last `days` days
+func buildListingStats(products []models.Product, days int) ([]ListingStats, error) {
+	productIDs := make([]uuid.UUID, 0, len(products))
+	for _, p := range products {
+		productIDs = append(productIDs, p.ID)
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	since := today.AddDate(0, 0, -(days - 1))
+	utcDay := "DATE(created_at AT TIME ZONE 'UTC')"
+
+	views, err := dailyCounts("product_views", "day", "day", productIDs, since)
+	if err != nil {
+		return nil, err
+	}
+	favorites, err := dailyCounts("favorites", utcDay, "created_at", productIDs, since)
+	if err != nil {
+		return nil, err
+	}
+	requests, err := dailyCounts("purchase_requests", utcDay, "created_at", productIDs, since)
+	if err != nil {
+		return nil, err
+	}
+
+	totalViews, err := totalCounts("product_views", productIDs)
+	if err != nil {
+		return nil, err
+	}
+	totalFavorites, err := totalCounts("favorites", productIDs)
+	if err != nil {
+		return nil, err
+	}
+	totalRequests, err := totalCounts("purchase_requests", productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]ListingStats, 0, len(products))
+	for _, p := range products {
+		s := ListingStats{
+			ProductID:      p.ID.String(),
+			Title:          p.Title,
+			Price:          p.Price,
+			Status:         p.Status,
+			PostedAt:       p.CreatedAt.Format("2006-01-02T15:04:05.000Z"),
+			TotalViews:     totalViews[p.ID],
+			TotalFavorites: totalFavorites[p.ID],
+			TotalRequests:  totalRequests[p.ID],
+			Series:         make([]ListingStatsPoint, 0, days),
+		}
+		for d := since; !d.After(today); d = d.AddDate(0, 0, 1) {
+			key := d.Format(statsDateFormat)
+			s.Series = append(s.Series, ListingStatsPoint{
+				Date:      key,
+				Views:     views[p.ID][key],
+				Favorites: favorites[p.ID][key],
+				Requests:  requests[p.ID][key],
+			})
+		}
+		stats = append(stats, s)
+	}
+	return stats, nil
+}
+
+// dailyCounts groups rows of a table by product and the day given by dayExpr
+func dailyCounts(table, dayExpr, dateColumn string, productIDs []uuid.UUID, since time.Time) (map[uuid.UUID]map[string]int64, error) {
+	var rows []struct {
+		ProductID uuid.UUID
+		Day       time.Time
+		Count     int64
+	}
+	err := config.DB.Table(table).
+		Select("product_id, "+dayExpr+" AS day, COUNT(*) AS count").
+		Where("product_id IN ? AND "+dateColumn+" >= ?", productIDs, since).
+		Group("product_id, " + dayExpr).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[uuid.UUID]map[string]int64{}
+	for _, r := range rows {
+		if counts[r.ProductID] == nil {
+			counts[r.ProductID] = map[string]int64{}
+		}
+		counts[r.ProductID][r.Day.Format(statsDateFormat)] = r.Count
+	}
+	return counts, nil
+}
+
+// totalCounts counts all rows of a table per product
+func totalCounts(table string, productIDs []uuid.UUID) (map[uuid.UUID]int64, error) {
+	var rows []struct {
+		ProductID uuid.UUID
+		Count     int64
+	}
+	err := config.DB.Table(table).
+		Select("product_id, COUNT(*) AS count").
+		Where("product_id IN ?", productIDs).
+		Group("product_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[uuid.UUID]int64{}
+	for _, r := range rows {
+		counts[r.ProductID] = r.Count
+	}
+	return counts, nil
+}
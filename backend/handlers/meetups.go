@@ -0,0 +1,641 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"marketplace-backend/config"
+	"marketplace-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const maxMeetupSlots = 5
+
+// MeetupSlotInput is a candidate time window sent by the client
+type MeetupSlotInput struct {
+	StartsAt time.Time `json:"starts_at" binding:"required"`
+	EndsAt   time.Time `json:"ends_at" binding:"required"`
+}
+
+// MeetupProposalRequest is used both for the initial proposal and for rescheduling
+type MeetupProposalRequest struct {
+	LocationID uuid.UUID         `json:"location_id" binding:"required"`
+	Slots      []MeetupSlotInput `json:"slots" binding:"required,min=1,dive"`
+	Note       string            `json:"note"`
+}
+
+// SafeExchangeSpotRequest for admin management of exchange spots
+type SafeExchangeSpotRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	Address     string `json:"address"`
+	IsActive    *bool  `json:"is_active"`
+}
+
+// GetSafeExchangeSpots returns the active exchange spots for the user's college
+func GetSafeExchangeSpots(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var user models.User
+	if err := config.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	var spots []models.SafeExchangeSpot
+	result := config.DB.Where("college_id = ? AND is_active = ?", user.CollegeID, true).Order("name ASC").Find(&spots)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch exchange spots"})
+		return
+	}
+
+	c.JSON(http.StatusOK, spots)
+}
+
+// CreateSafeExchangeSpot adds an exchange spot to the admin's college
+func CreateSafeExchangeSpot(c *gin.Context) {
+	var req SafeExchangeSpotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	admin, ok := loadSpotAdmin(c)
+	if !ok {
+		return
+	}
+
+	spot := models.SafeExchangeSpot{
+		CollegeID:   admin.CollegeID,
+		Name:        strings.TrimSpace(req.Name),
+		Description: req.Description,
+		Address:     req.Address,
+		IsActive:    true,
+	}
+	if req.IsActive != nil {
+		spot.IsActive = *req.IsActive
+	}
+
+	if err := config.DB.Create(&spot).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create exchange spot"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, spot)
+}
+
+// UpdateSafeExchangeSpot updates an exchange spot of the admin's college
+func UpdateSafeExchangeSpot(c *gin.Context) {
+	spotID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid spot ID"})
+		return
+	}
+
+	admin, ok := loadSpotAdmin(c)
+	if !ok {
+		return
+	}
+
+	var spot models.SafeExchangeSpot
+	if err := config.DB.Where("college_id = ?", admin.CollegeID).First(&spot, spotID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Exchange spot not found"})
+		return
+	}
+
+	var req SafeExchangeSpotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updates := map[string]interface{}{
+		"name":        strings.TrimSpace(req.Name),
+		"description": req.Description,
+		"address":     req.Address,
+	}
+	if req.IsActive != nil {
+		updates["is_active"] = *req.IsActive
+	}
+
+	if err := config.DB.Model(&spot).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update exchange spot"})
+		return
+	}
+
+	c.JSON(http.StatusOK, spot)
+}
+
+// DeleteSafeExchangeSpot deactivates an exchange spot of the admin's college so existing meetups keep their location
+func DeleteSafeExchangeSpot(c *gin.Context) {
+	spotID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid spot ID"})
+		return
+	}
+
+	admin, ok := loadSpotAdmin(c)
+	if !ok {
+		return
+	}
+
+	result := config.DB.Model(&models.SafeExchangeSpot{}).
+		Where("id = ? AND college_id = ?", spotID, admin.CollegeID).
+		Update("is_active", false)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove exchange spot"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Exchange spot not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Exchange spot removed successfully"})
+}
+
+// loadSpotAdmin loads the admin managing exchange spots; admins only manage their own college's spots
+func loadSpotAdmin(c *gin.Context) (*models.User, bool) {
+	userID, _ := c.Get("userID")
+	var admin models.User
+	if err := config.DB.First(&admin, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return nil, false
+	}
+	return &admin, true
+}
+
+// GetMeetup returns the meetup attached to a purchase request
+func GetMeetup(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	requestID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request ID"})
+		return
+	}
+
+	var request models.PurchaseRequest
+	if err := config.DB.First(&request, requestID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Purchase request not found"})
+		return
+	}
+	if request.BuyerID != userID && request.SellerID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You are not part of this purchase"})
+		return
+	}
+
+	var meetup models.Meetup
+	if err := preloadMeetup(config.DB).Where("purchase_request_id = ?", requestID).First(&meetup).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No meetup proposed yet"})
+		return
+	}
+
+	c.JSON(http.StatusOK, meetup)
+}
+
+// ProposeMeetup creates the first meetup proposal for an accepted purchase request
+func ProposeMeetup(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	requestID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request ID"})
+		return
+	}
+
+	var req MeetupProposalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var request models.PurchaseRequest
+	if err := config.DB.Preload("Product", withDeleted).First(&request, requestID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Purchase request not found"})
+		return
+	}
+	if request.BuyerID != userID && request.SellerID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You are not part of this purchase"})
+		return
+	}
+	if !meetupRequestOpen(c, &request) {
+		return
+	}
+
+	// A declined meetup is replaced by the new proposal; a live one has to be rescheduled
+	var existing models.Meetup
+	if config.DB.Where("purchase_request_id = ?", requestID).First(&existing).Error == nil && existing.Status != "declined" {
+		c.JSON(http.StatusConflict, gin.H{"error": "A meetup already exists for this request, reschedule it instead"})
+		return
+	}
+
+	spot, err := validateMeetupProposal(&req, request.CollegeID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var chat models.Chat
+	if err := config.DB.Where("purchase_request_id = ?", requestID).First(&chat).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Chat for this request not found"})
+		return
+	}
+
+	meetup := models.Meetup{
+		PurchaseRequestID: request.ID,
+		ChatID:            chat.ID,
+		ProposedByID:      userID.(uuid.UUID),
+		LocationID:        spot.ID,
+		Slots:             meetupSlotsFromInput(req.Slots),
+		Note:              req.Note,
+		Status:            "proposed",
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if existing.ID != uuid.Nil {
+			if err := tx.Delete(&existing).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Create(&meetup).Error; err != nil {
+			return err
+		}
+		text := fmt.Sprintf("Proposed a meetup at %s: %s", spot.Name, describeMeetupSlots(meetup.Slots))
+		return postSystemMessage(tx, chat.ID, meetup.ProposedByID, text)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create meetup"})
+		return
+	}
+
+	preloadMeetup(config.DB).First(&meetup, meetup.ID)
+
+	c.JSON(http.StatusCreated, meetup)
+}
+
+// AcceptMeetup accepts one of the proposed slots
+func AcceptMeetup(c *gin.Context) {
+	meetup, userID, ok := loadMeetupForParticipant(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		SlotID uuid.UUID `json:"slot_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if meetup.Status != "proposed" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only proposed meetups can be accepted"})
+		return
+	}
+	if meetup.ProposedByID == userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "The other party has to accept your proposal"})
+		return
+	}
+
+	var slot *models.MeetupSlot
+	for i := range meetup.Slots {
+		if meetup.Slots[i].ID == req.SlotID {
+			slot = &meetup.Slots[i]
+		}
+	}
+	if slot == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Slot does not belong to this meetup"})
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(meetup).Updates(map[string]interface{}{
+			"status":           "accepted",
+			"accepted_slot_id": slot.ID,
+		}).Error; err != nil {
+			return err
+		}
+		text := fmt.Sprintf("Accepted the meetup at %s on %s", meetup.Location.Name, formatMeetupSlot(*slot))
+		return postSystemMessage(tx, meetup.ChatID, userID, text)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to accept meetup"})
+		return
+	}
+
+	preloadMeetup(config.DB).First(meetup, meetup.ID)
+
+	c.JSON(http.StatusOK, meetup)
+}
+
+// DeclineMeetup declines the current proposal
+func DeclineMeetup(c *gin.Context) {
+	meetup, userID, ok := loadMeetupForParticipant(c)
+	if !ok {
+		return
+	}
+
+	if meetup.Status != "proposed" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only proposed meetups can be declined"})
+		return
+	}
+	if meetup.ProposedByID == userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Reschedule your own proposal instead of declining it"})
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(meetup).Update("status", "declined").Error; err != nil {
+			return err
+		}
+		return postSystemMessage(tx, meetup.ChatID, userID, "Declined the proposed meetup")
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decline meetup"})
+		return
+	}
+
+	preloadMeetup(config.DB).First(meetup, meetup.ID)
+
+	c.JSON(http.StatusOK, meetup)
+}
+
+// RescheduleMeetup replaces the location and slots and hands the decision to the other party
+func RescheduleMeetup(c *gin.Context) {
+	meetup, userID, ok := loadMeetupForParticipant(c)
+	if !ok {
+		return
+	}
+
+	var req MeetupProposalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if meetup.Status != "proposed" && meetup.Status != "accepted" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only proposed or accepted meetups can be rescheduled, propose a new one instead"})
+		return
+	}
+	if err := config.DB.Preload("Product", withDeleted).First(&meetup.PurchaseRequest, meetup.PurchaseRequestID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Purchase request not found"})
+		return
+	}
+	if !meetupRequestOpen(c, &meetup.PurchaseRequest) {
+		return
+	}
+
+	spot, err := validateMeetupProposal(&req, meetup.PurchaseRequest.CollegeID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	slots := meetupSlotsFromInput(req.Slots)
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("meetup_id = ?", meetup.ID).Delete(&models.MeetupSlot{}).Error; err != nil {
+			return err
+		}
+		for i := range slots {
+			slots[i].MeetupID = meetup.ID
+		}
+		if err := tx.Create(&slots).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(meetup).Updates(map[string]interface{}{
+			"status":           "proposed",
+			"proposed_by_id":   userID,
+			"location_id":      spot.ID,
+			"accepted_slot_id": nil,
+			"note":             req.Note,
+		}).Error; err != nil {
+			return err
+		}
+		text := fmt.Sprintf("Suggested a new meetup at %s: %s", spot.Name, describeMeetupSlots(slots))
+		return postSystemMessage(tx, meetup.ChatID, userID, text)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reschedule meetup"})
+		return
+	}
+
+	preloadMeetup(config.DB).First(meetup, meetup.ID)
+
+	c.JSON(http.StatusOK, meetup)
+}
+
+// GetMeetupCalendar exports an accepted meetup as an iCalendar file
+func GetMeetupCalendar(c *gin.Context) {
+	meetup, _, ok := loadMeetupForParticipant(c)
+	if !ok {
+		return
+	}
+
+	if meetup.Status != "accepted" || meetup.AcceptedSlotID == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Meetup has not been agreed yet"})
+		return
+	}
+
+	var slot models.MeetupSlot
+	for _, s := range meetup.Slots {
+		if s.ID == *meetup.AcceptedSlotID {
+			slot = s
+		}
+	}
+
+	var product models.Product
+	config.DB.Unscoped().First(&product, meetup.PurchaseRequest.ProductID)
+
+	ics := buildMeetupICS(meetup, slot, product.Title)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"meetup-%s.ics\"", meetup.ID))
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(ics))
+}
+
+// meetupRequestOpen checks that a meetup can still be arranged for the request: it has to be accepted
+// and its listing not removed. Product must be preloaded with withDeleted.
+func meetupRequestOpen(c *gin.Context, request *models.PurchaseRequest) bool {
+	if request.Status != "accepted" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Meetups can only be scheduled for accepted requests"})
+		return false
+	}
+	if request.Product.DeletedAt.Valid {
+		c.JSON(http.StatusConflict, gin.H{"error": "This listing was removed"})
+		return false
+	}
+	return true
+}
+
+// loadMeetupForParticipant loads the meetup from the :id param and checks the caller is buyer or seller
+func loadMeetupForParticipant(c *gin.Context) (*models.Meetup, uuid.UUID, bool) {
+	userIDValue, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return nil, uuid.Nil, false
+	}
+	userID := userIDValue.(uuid.UUID)
+
+	meetupID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid meetup ID"})
+		return nil, uuid.Nil, false
+	}
+
+	var meetup models.Meetup
+	if err := preloadMeetup(config.DB).Preload("PurchaseRequest").First(&meetup, meetupID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Meetup not found"})
+		return nil, uuid.Nil, false
+	}
+
+	if meetup.PurchaseRequest.BuyerID != userID && meetup.PurchaseRequest.SellerID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You are not part of this purchase"})
+		return nil, uuid.Nil, false
+	}
+
+	return &meetup, userID, true
+}
+
+func preloadMeetup(db *gorm.DB) *gorm.DB {
+	return db.Preload("Location").Preload("Slots", func(db *gorm.DB) *gorm.DB {
+		return db.Order("starts_at ASC")
+	})
+}
+
+// validateMeetupProposal checks the slots and that the location is an active spot of the college
+func validateMeetupProposal(req *MeetupProposalRequest, collegeID uuid.UUID) (*models.SafeExchangeSpot, error) {
+	if len(req.Slots) > maxMeetupSlots {
+		return nil, fmt.Errorf("at most %d time slots can be proposed", maxMeetupSlots)
+	}
+
+	now := time.Now()
+	for _, slot := range req.Slots {
+		if !slot.EndsAt.After(slot.StartsAt) {
+			return nil, fmt.Errorf("slot end time must be after its start time")
+		}
+		if slot.StartsAt.Before(now) {
+			return nil, fmt.Errorf("slots must be in the future")
+		}
+		if slot.EndsAt.Sub(slot.StartsAt) > 4*time.Hour {
+			return nil, fmt.Errorf("slots can be at most 4 hours long")
+		}
+	}
+
+	var spot models.SafeExchangeSpot
+	err := config.DB.Where("id = ? AND college_id = ? AND is_active = ?", req.LocationID, collegeID, true).First(&spot).Error
+	if err != nil {
+		return nil, fmt.Errorf("location must be one of your college's safe exchange spots")
+	}
+
+	return &spot, nil
+}
+
+func meetupSlotsFromInput(input []MeetupSlotInput) []models.MeetupSlot {
+	slots := make([]models.MeetupSlot, 0, len(input))
+	for _, s := range input {
+		slots = append(slots, models.MeetupSlot{StartsAt: s.StartsAt.UTC(), EndsAt: s.EndsAt.UTC()})
+	}
+	return slots
+}
+
+func formatMeetupSlot(slot models.MeetupSlot) string {
+	return fmt.Sprintf("%s – %s UTC", slot.StartsAt.UTC().Format("Mon Jan 2 15:04"), slot.EndsAt.UTC().Format("15:04"))
+}
+
+func describeMeetupSlots(slots []models.MeetupSlot) string {
+	parts := make([]string, 0, len(slots))
+	for _, slot := range slots {
+		parts = append(parts, formatMeetupSlot(slot))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// postSystemMessage writes an app-generated message into a chat
+func postSystemMessage(tx *gorm.DB, chatID, fromID uuid.UUID, text string) error {
+	message := models.Message{
+		ChatID:   chatID,
+		FromID:   fromID,
+		Text:     text,
+		IsSystem: true,
+	}
+	return tx.Create(&message).Error
+}
+
+// buildMeetupICS renders a single-event iCalendar document (RFC 5545)
+func buildMeetupICS(meetup *models.Meetup, slot models.MeetupSlot, productTitle string) string {
+	const stamp = "20060102T150405Z"
+
+	description := "Campus marketplace pickup"
+	if meetup.Note != "" {
+		description += "\n" + meetup.Note
+	}
+	location := meetup.Location.Name
+	if meetup.Location.Address != "" {
+		location += ", " + meetup.Location.Address
+	}
+
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Campus Marketplace//Meetups//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"BEGIN:VEVENT",
+		"UID:" + meetup.ID.String() + "@marketplace",
+		"DTSTAMP:" + meetup.UpdatedAt.UTC().Format(stamp),
+		"DTSTART:" + slot.StartsAt.UTC().Format(stamp),
+		"DTEND:" + slot.EndsAt.UTC().Format(stamp),
+		"SUMMARY:" + escapeICSText("Pickup: "+productTitle),
+		"LOCATION:" + escapeICSText(location),
+		"DESCRIPTION:" + escapeICSText(description),
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}
+
+	var b strings.Builder
+	for _, line := range lines {
+		b.WriteString(foldICSLine(line))
+		b.WriteString("\r\n")
+	}
+	return b.String()
+}
+
+func escapeICSText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// foldICSLine wraps content lines longer than 75 octets without splitting UTF-8 sequences
+func foldICSLine(line string) string {
+	if len(line) <= 75 {
+		return line
+	}
+
+	var b strings.Builder
+	width := 0
+	for _, r := range line {
+		size := len(string(r))
+		if width+size > 75 {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	return b.String()
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"marketplace-backend/models"
+
+	"github.com/google/uuid"
+)
+
+func TestEscapeICSText(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Library entrance", "Library entrance"},
+		{"Main St, Building 4; Room 2", `Main St\, Building 4\; Room 2`},
+		{`C:\path`, `C:\\path`},
+		{"line one\nline two", `line one\nline two`},
+		{"line one\r\nline two", `line one\nline two`},
+	}
+	for _, tt := range tests {
+		if got := escapeICSText(tt.in); got != tt.want {
+			t.Errorf("escapeICSText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFoldICSLine(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+	}{
+		{"short", "SUMMARY:Pickup: Calculus"},
+		{"exactly 75 octets", "DESCRIPTION:" + strings.Repeat("a", 63)},
+		{"long ascii", "DESCRIPTION:" + strings.Repeat("abcdefghij", 20)},
+		{"long multibyte", "LOCATION:" + strings.Repeat("Café Ünïversität ☕ ", 10)},
+	}
+	for _, tt := range tests {
+		folded := foldICSLine(tt.line)
+		if len(tt.line) <= 75 && folded != tt.line {
+			t.Errorf("%s: short line was folded: %q", tt.name, folded)
+		}
+		for _, physical := range strings.Split(folded, "\r\n") {
+			if len(physical) > 75 {
+				t.Errorf("%s: line of %d octets: %q", tt.name, len(physical), physical)
+			}
+			if !utf8.ValidString(physical) {
+				t.Errorf("%s: fold split a UTF-8 sequence: %q", tt.name, physical)
+			}
+		}
+		if unfolded := strings.ReplaceAll(folded, "\r\n ", ""); unfolded != tt.line {
+			t.Errorf("%s: unfolding gives %q, want %q", tt.name, unfolded, tt.line)
+		}
+	}
+}
+
+func TestBuildMeetupICS(t *testing.T) {
+	start := time.Date(2026, 3, 14, 15, 0, 0, 0, time.UTC)
+	meetup := &models.Meetup{
+		ID:        uuid.MustParse("6f1b6a8e-3c1d-4d2e-9a51-0c7d3e6b2f10"),
+		Note:      "Bring the charger, please",
+		Location:  models.SafeExchangeSpot{Name: "Library", Address: "1 College Rd, North Campus"},
+		UpdatedAt: start.Add(-time.Hour),
+	}
+	slot := models.MeetupSlot{StartsAt: start, EndsAt: start.Add(30 * time.Minute)}
+
+	ics := buildMeetupICS(meetup, slot, "Calculus; 8th edition")
+
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"UID:6f1b6a8e-3c1d-4d2e-9a51-0c7d3e6b2f10@marketplace\r\n",
+		"DTSTART:20260314T150000Z\r\n",
+		"DTEND:20260314T153000Z\r\n",
+		`SUMMARY:Pickup: Calculus\; 8th edition` + "\r\n",
+		`LOCATION:Library\, 1 College Rd\, North Campus` + "\r\n",
+		`DESCRIPTION:Campus marketplace pickup\nBring the charger\, please` + "\r\n",
+	} {
+		if !strings.Contains(ics, want) {
+			t.Errorf("calendar is missing %q:\n%s", want, ics)
+		}
+	}
+	if !strings.HasSuffix(ics, "END:VCALENDAR\r\n") {
+		t.Errorf("calendar does not end with END:VCALENDAR and CRLF:\n%s", ics)
+	}
+	if strings.Contains(strings.ReplaceAll(ics, "\r\n", ""), "\n") {
+		t.Error("calendar contains a bare LF")
+	}
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"log"
+
+	"marketplace-backend/config"
+	"marketplace-backend/models"
+
+	"github.com/google/uuid"
+)
+
+// Notification event types
+const (
+	NotificationEventPurchaseRequestCreated  = "purchase_request.created"
+	NotificationEventPurchaseRequestAccepted = "purchase_request.accepted"
+	NotificationEventPurchaseRequestDeclined = "purchase_request.declined"
+	NotificationEventMessageCreated          = "message.created"
+	NotificationEventFavoriteCreated         = "favorite.created"
+	NotificationEventSavedSearchMatch        = "saved_search.match"
+	NotificationEventPriceDrop               = "product.price_drop"
+	NotificationEventListingPublished        = "listing.published"
+	NotificationEventListingExpiring         = "listing.expiring"
+	NotificationEventListingExpired          = "listing.expired"
+	NotificationEventListingImportCompleted  = "listing.import_completed"
+	NotificationEventDataExportReady         = "account.export_ready"
+	NotificationEventDailyDigest             = "digest.daily" // email only
+)
+
+// NotificationEventTypes lists every event type a user can set preferences for
+var NotificationEventTypes = []string{
+	NotificationEventPurchaseRequestCreated,
+	NotificationEventPurchaseRequestAccepted,
+	NotificationEventPurchaseRequestDeclined,
+	NotificationEventMessageCreated,
+	NotificationEventFavoriteCreated,
+	NotificationEventSavedSearchMatch,
+	NotificationEventPriceDrop,
+	NotificationEventListingPublished,
+	NotificationEventListingExpiring,
+	NotificationEventListingExpired,
+	NotificationEventListingImportCompleted,
+	NotificationEventDataExportReady,
+	NotificationEventDailyDigest,
+}
+
+// NotificationEvent is something that happened to a user that they may want to hear about
+type NotificationEvent struct {
+	UserID uuid.UUID
+	Type   string
+	Title  string
+	Body   string
+	Link   string
+}
+
+// isNotificationEventType reports whether eventType is a known event type
+func isNotificationEventType(eventType string) bool {
+	for _, t := range NotificationEventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// notificationPreferenceFor returns the user's preference for an event type, or the defaults
+func notificationPreferenceFor(userID uuid.UUID, eventType string) models.NotificationPreference {
+	pref := models.NotificationPreference{UserID: userID, EventType: eventType, InApp: true, Push: true}
+	config.DB.Where("user_id = ? AND event_type = ?", userID, eventType).First(&pref)
+	return pref
+}
+
+// notify records an event for a user and emails/pushes it according to their preferences.
+// Failures are logged and never surfaced to the request that triggered the event.
+func notify(event NotificationEvent) {
+	pref := notificationPreferenceFor(event.UserID, event.Type)
+
+	if pref.Email && emailEventTypes[event.Type] {
+		enqueueNotificationEmail(event)
+	}
+
+	if pref.Push && pushEventTypes[event.Type] {
+		go sendPushNotification(event)
+	}
+
+	if !pref.InApp {
+		return
+	}
+
+	notification := models.Notification{
+		UserID: event.UserID,
+		Type:   event.Type,
+		Title:  event.Title,
+		Body:   event.Body,
+		Link:   event.Link,
+	}
+	if err := config.DB.Create(&notification).Error; err != nil {
+		log.Printf("Failed to record %s notification for user %s: %v", event.Type, event.UserID, err)
+	}
+}
+
+// truncateText shortens text to at most max runes, adding an ellipsis when cut
+func truncateText(text string, max int) string {
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	return string(runes[:max-1]) + "…"
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"marketplace-backend/config"
+	"marketplace-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// priceDropWindow is how far back we look for earlier prices when deciding whether a drop is genuine
+const priceDropWindow = 30 * 24 * time.Hour
+
+// recordPrice appends a price point to the product's history
+func recordPrice(db *gorm.DB, productID uuid.UUID, price float64) error {
+	return db.Create(&models.ProductPriceHistory{ProductID: productID, Price: price}).Error
+}
+
+// handlePriceChange records a new price and alerts favoriters when it is a genuine drop:
+// lower than the old price and lower than anything the product was listed at in the last 30 days.
+func handlePriceChange(product models.Product, oldPrice float64) {
+	if product.Price == oldPrice {
+		return
+	}
+
+	// Products listed before price tracking have no history yet; seed it with the old price
+	var count int64
+	config.DB.Model(&models.ProductPriceHistory{}).Where("product_id = ?", product.ID).Count(&count)
+	if count == 0 {
+		recordPrice(config.DB, product.ID, oldPrice)
+	}
+
+	var lowestRecent sql.NullFloat64
+	config.DB.Model(&models.ProductPriceHistory{}).
+		Where("product_id = ? AND created_at > ?", product.ID, time.Now().Add(-priceDropWindow)).
+		Select("MIN(price)").
+		Row().
+		Scan(&lowestRecent)
+	if !lowestRecent.Valid {
+		lowestRecent.Float64 = oldPrice
+	}
+
+	if err := recordPrice(config.DB, product.ID, product.Price); err != nil {
+		log.Printf("Failed to record price history for product %s: %v", product.ID, err)
+	}
+
+	if product.Price >= oldPrice || product.Price >= lowestRecent.Float64 {
+		return
+	}
+
+	var favorites []models.Favorite
+	if err := config.DB.Where("product_id = ? AND user_id <> ?", product.ID, product.SellerID).Find(&favorites).Error; err != nil {
+		log.Printf("Failed to load favorites for price drop on product %s: %v", product.ID, err)
+		return
+	}
+
+	for _, favorite := range favorites {
+		if usersBlocked(favorite.UserID, product.SellerID) {
+			continue
+		}
+		body := fmt.Sprintf("%s dropped from $%.2f to $%.2f", product.Title, oldPrice, product.Price)
+		if favorite.PriceWhenFavorited > oldPrice {
+			body += fmt.Sprintf(" ($%.2f less than when you saved it)", favorite.PriceWhenFavorited-product.Price)
+		}
+
+		notify(NotificationEvent{
+			UserID: favorite.UserID,
+			Type:   NotificationEventPriceDrop,
+			Title:  "Price drop on a favorite",
+			Body:   body,
+			Link:   "/product/" + product.ID.String(),
+		})
+	}
+}
+
+// previousPriceFor returns the last higher price before the current one, for "was $120, now $95"
+func previousPriceFor(product *models.Product) *float64 {
+	var history []models.ProductPriceHistory
+	config.DB.Where("product_id = ?", product.ID).Order("created_at DESC").Limit(2).Find(&history)
+
+	if len(history) < 2 || history[0].Price != product.Price || history[1].Price <= product.Price {
+		return nil
+	}
+	return &history[1].Price
+}
+
+// GetProductPriceHistory returns the price history of a product, oldest first
+func GetProductPriceHistory(c *gin.Context) {
+	productID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	var history []models.ProductPriceHistory
+	if err := config.DB.Where("product_id = ?", productID).Order("created_at ASC").Find(&history).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch price history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
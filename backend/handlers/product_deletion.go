@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"marketplace-backend/config"
+	"marketplace-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// withDeleted preloads a product or user even after it was soft-deleted, so chats and requests keep their context
+func withDeleted(db *gorm.DB) *gorm.DB {
+	return db.Unscoped()
+}
+
+// RestoreProduct undoes a delete while the listing is still inside the restore window
+func RestoreProduct(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	productID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	var product models.Product
+	if err := config.DB.Unscoped().First(&product, productID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+	if product.SellerID != userID && !isAdminRequest(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only restore your own products"})
+		return
+	}
+	if !product.DeletedAt.Valid {
+		c.JSON(http.StatusConflict, gin.H{"error": "Product is not deleted"})
+		return
+	}
+	// Listings removed by an admin or moderator stay removed unless an admin restores them
+	if product.DeletedByID != nil && *product.DeletedByID != product.SellerID && !isAdminRequest(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "This listing was removed by a moderator and can't be restored"})
+		return
+	}
+	window := time.Duration(config.ProductRestoreWindowDays()) * 24 * time.Hour
+	if time.Since(product.DeletedAt.Time) > window {
+		c.JSON(http.StatusGone, gin.H{"error": fmt.Sprintf("Deleted listings can only be restored within %d days", config.ProductRestoreWindowDays())})
+		return
+	}
+
+	// Requests were declined on delete, so a requested listing comes back available
+	status := product.Status
+	if status == ProductStatusRequested {
+		status = ProductStatusAvailable
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Model(&product).Updates(map[string]interface{}{
+			"deleted_at":    nil,
+			"deleted_by_id": nil,
+			"restored_at":   time.Now(),
+			"status":        status,
+		}).Error
+		if err != nil {
+			return err
+		}
+		if err := postListingChatMessage(tx, &product, product.SellerID, "The seller restored this listing."); err != nil {
+			return err
+		}
+		return recordAudit(tx, c, AuditEntry{
+			Action:     AuditListingRestored,
+			TargetType: "product",
+			TargetID:   product.ID,
+			Before:     gin.H{"deleted": true},
+			After:      gin.H{"status": status, "deleted": false},
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore product"})
+		return
+	}
+	log.Printf("Product %s restored by %v", product.ID, userID)
+
+	config.DB.Preload("Seller").First(&product, product.ID)
+	c.JSON(http.StatusOK, ProductDTOFromModel(&product))
+}
+
+// removeListing soft-deletes a product, declines its open requests and leaves a marker in its chats.
+// It returns the declined requests so their buyers can be notified after the transaction commits.
+func removeListing(tx *gorm.DB, product *models.Product, actorID uuid.UUID) ([]models.PurchaseRequest, error) {
+	var pending []models.PurchaseRequest
+	if err := tx.Where("product_id = ? AND status = ?", product.ID, "pending").Find(&pending).Error; err != nil {
+		return nil, err
+	}
+	if len(pending) > 0 {
+		err := tx.Model(&models.PurchaseRequest{}).
+			Where("product_id = ? AND status = ?", product.ID, "pending").
+			Update("status", "declined").Error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	text := "The seller removed this listing."
+	if actorID != product.SellerID {
+		text = "This listing was removed by a moderator."
+	}
+	if err := postListingChatMessage(tx, product, actorID, text); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Model(product).Update("deleted_by_id", actorID).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Delete(product).Error; err != nil {
+		return nil, err
+	}
+	return pending, nil
+}
+
+// postListingChatMessage adds a system message to every chat about the product
+func postListingChatMessage(tx *gorm.DB, product *models.Product, fromID uuid.UUID, text string) error {
+	var chatIDs []uuid.UUID
+	if err := tx.Model(&models.Chat{}).Where("product_id = ?", product.ID).Pluck("id", &chatIDs).Error; err != nil {
+		return err
+	}
+	for _, chatID := range chatIDs {
+		if err := postSystemMessage(tx, chatID, fromID, text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// markRemovedListings flags chats whose product has been deleted; chats must preload Product withDeleted
+func markRemovedListings(chats []models.Chat) {
+	for i := range chats {
+		chats[i].ListingRemoved = chats[i].Product.DeletedAt.Valid
+	}
+}
+
+// purgeDeletedListingFavorites drops favorites and collection items of listings past the restore window
+func purgeDeletedListingFavorites() {
+	cutoff := time.Now().AddDate(0, 0, -config.ProductRestoreWindowDays())
+	expired := config.DB.Unscoped().Model(&models.Product{}).Select("id").Where("deleted_at <= ?", cutoff)
+
+	result := config.DB.Where("product_id IN (?)", expired).Delete(&models.Favorite{})
+	if result.Error != nil {
+		log.Printf("Failed to purge favorites of deleted listings: %v", result.Error)
+		return
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("Purged %d favorites of deleted listings", result.RowsAffected)
+	}
+
+	if err := config.DB.Where("product_id IN (?)", expired).Delete(&models.CollectionItem{}).Error; err != nil {
+		log.Printf("Failed to purge collection items of deleted listings: %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"marketplace-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ProductFilter holds the listing filters shared by GetProducts and saved searches
+type ProductFilter struct {
+	Keywords   string   `json:"keywords"`
+	Category   string   `json:"category"`
+	MaxPrice   *float64 `json:"max_price"`
+	Condition  string   `json:"condition"`
+	ISBN       string   `json:"isbn"`        // ISBN-13
+	CourseCode string   `json:"course_code"` // normalized, e.g. MATH101
+	// Attributes filters on listing attributes; "key.min"/"key.max" are numeric bounds
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// productFilterFromQuery reads ?q=&category=&max_price=&condition=&isbn=&course=&attr.<key>= from the request
+func productFilterFromQuery(c *gin.Context) ProductFilter {
+	filter := ProductFilter{
+		Keywords:   strings.TrimSpace(c.Query("q")),
+		Category:   strings.TrimSpace(c.Query("category")),
+		Condition:  strings.TrimSpace(c.Query("condition")),
+		ISBN:       normalizeISBNFilter(c.Query("isbn")),
+		CourseCode: normalizeCourseCode(c.Query("course")),
+		Attributes: attributeFiltersFromQuery(c.Request.URL.Query()),
+	}
+	// A scanned or pasted ISBN in the search box searches by ISBN
+	if filter.ISBN == "" {
+		if isbn, err := normalizeISBN(filter.Keywords); err == nil {
+			filter.ISBN, filter.Keywords = isbn, ""
+		}
+	}
+	if maxPrice, err := strconv.ParseFloat(c.Query("max_price"), 64); err == nil {
+		filter.MaxPrice = &maxPrice
+	}
+	return filter
+}
+
+// keywordTerms splits the keywords into lowercase terms that must all match
+func (f ProductFilter) keywordTerms() []string {
+	return strings.Fields(strings.ToLower(f.Keywords))
+}
+
+// Apply adds the filter conditions to a products query
+func (f ProductFilter) Apply(db *gorm.DB) *gorm.DB {
+	for _, term := range f.keywordTerms() {
+		like := "%" + escapeLike(term) + "%"
+		db = db.Where("(LOWER(products.title) LIKE ? ESCAPE '\\' OR LOWER(products.description) LIKE ? ESCAPE '\\' OR LOWER(products.tags) LIKE ? ESCAPE '\\')", like, like, like)
+	}
+	if f.Category != "" {
+		// A taxonomy category also matches listings in its subcategories
+		if ids := categoryFilterIDs(f.Category); ids != nil {
+			db = db.Where("products.category_id IN ?", ids)
+		} else {
+			db = db.Where("LOWER(products.category) = LOWER(?)", f.Category)
+		}
+	}
+	if f.MaxPrice != nil {
+		db = db.Where("products.price <= ?", *f.MaxPrice)
+	}
+	if f.Condition != "" {
+		db = db.Where("LOWER(products.condition) = LOWER(?)", f.Condition)
+	}
+	if f.ISBN != "" {
+		db = db.Where("products.isbn = ?", f.ISBN)
+	}
+	if f.CourseCode != "" {
+		db = db.Where("products.course_code = ?", f.CourseCode)
+	}
+	return applyAttributeFilters(db, f.Attributes)
+}
+
+// Matches reports whether a single product satisfies the filter, using the same rules as Apply
+func (f ProductFilter) Matches(product *models.Product) bool {
+	if f.Category != "" && !f.matchesCategory(product) {
+		return false
+	}
+	if f.MaxPrice != nil && product.Price > *f.MaxPrice {
+		return false
+	}
+	if f.Condition != "" && !strings.EqualFold(f.Condition, product.Condition) {
+		return false
+	}
+	if f.ISBN != "" && f.ISBN != product.ISBN {
+		return false
+	}
+	if f.CourseCode != "" && f.CourseCode != product.CourseCode {
+		return false
+	}
+	if !matchesAttributeFilters(product, f.Attributes) {
+		return false
+	}
+
+	haystack := strings.ToLower(product.Title + "\n" + product.Description + "\n" + product.Tags)
+	for _, term := range f.keywordTerms() {
+		if !strings.Contains(haystack, term) {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeISBNFilter normalizes a valid ISBN and otherwise just strips separators,
+// so an invalid ISBN filters to no results instead of being ignored
+func normalizeISBNFilter(raw string) string {
+	if isbn, err := normalizeISBN(raw); err == nil {
+		return isbn
+	}
+	return strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(raw))
+}
+
+func (f ProductFilter) matchesCategory(product *models.Product) bool {
+	ids := categoryFilterIDs(f.Category)
+	if ids == nil {
+		return strings.EqualFold(f.Category, product.Category)
+	}
+	if product.CategoryID == nil {
+		return false
+	}
+	for _, id := range ids {
+		if id == *product.CategoryID {
+			return true
+		}
+	}
+	return false
+}
+
+// IsEmpty reports whether no filter field is set
+func (f ProductFilter) IsEmpty() bool {
+	return f.Keywords == "" && f.Category == "" && f.MaxPrice == nil && f.Condition == "" &&
+		f.ISBN == "" && f.CourseCode == "" && len(f.Attributes) == 0
+}
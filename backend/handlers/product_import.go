@@ -0,0 +1,456 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"marketplace-backend/config"
+	"marketplace-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	maxImportRows           = 100
+	maxImportFileSize       = 5 << 20
+	maxImportZipSize        = 50 << 20
+	maxImportImageSize      = 10 << 20
+	importStalledAfter      = 30 * time.Minute
+	importImageFetchTimeout = 15 * time.Second
+)
+
+// importRow is one listing to create: the same fields as the create form plus image references,
+// which are http(s) URLs or file names inside the uploaded zip
+type importRow struct {
+	Input  ProductInput
+	Images []string
+}
+
+// ProductImportResponse is an import's progress plus the per-row report so far
+type ProductImportResponse struct {
+	models.ProductImport
+	Progress int                       `json:"progress"` // percent of rows processed
+	Rows     []models.ProductImportRow `json:"rows"`
+}
+
+// importImageClient only reaches public https hosts, so import rows can't be used to probe internal services
+var importImageClient = config.NewPublicHTTPClient(importImageFetchTimeout)
+
+// ImportProducts accepts a CSV or JSON file of listings (field "file") and an optional zip of images
+// (field "images") and creates the listings in the background. Poll GET /api/products/import/:id for progress.
+func ImportProducts(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var user models.User
+	if err := config.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User not found"})
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A CSV or JSON file is required in the \"file\" field"})
+		return
+	}
+	if file.Size > maxImportFileSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Import files are limited to %d MB", maxImportFileSize>>20)})
+		return
+	}
+	data, err := readFormFile(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read import file"})
+		return
+	}
+
+	format := strings.TrimPrefix(strings.ToLower(path.Ext(file.Filename)), ".")
+	var rows []importRow
+	switch format {
+	case "csv":
+		rows, err = parseImportCSV(data)
+	case "json":
+		rows, err = parseImportJSON(data)
+	default:
+		err = fmt.Errorf("Import file must be .csv or .json")
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(rows) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Import file has no listings"})
+		return
+	}
+	if len(rows) > maxImportRows {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d listings can be imported at once", maxImportRows)})
+		return
+	}
+
+	var images map[string]*zip.File
+	if zipFile, err := c.FormFile("images"); err == nil {
+		if zipFile.Size > maxImportZipSize {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Image archives are limited to %d MB", maxImportZipSize>>20)})
+			return
+		}
+		if images, err = readImportZip(zipFile); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	job := models.ProductImport{
+		UserID:    user.ID,
+		Filename:  file.Filename,
+		Format:    format,
+		Status:    "pending",
+		TotalRows: len(rows),
+		Report:    "[]",
+	}
+	if err := config.DB.Create(&job).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start import"})
+		return
+	}
+
+	go runProductImport(job, user, rows, images)
+
+	c.Header("Location", "/api/products/import/"+job.ID.String())
+	c.JSON(http.StatusAccepted, productImportResponse(&job))
+}
+
+// GetProductImport returns the progress and per-row report of one of the caller's imports
+func GetProductImport(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	importID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid import ID"})
+		return
+	}
+
+	var job models.ProductImport
+	if err := config.DB.Where("id = ? AND user_id = ?", importID, userID).First(&job).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Import not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, productImportResponse(&job))
+}
+
+func productImportResponse(job *models.ProductImport) ProductImportResponse {
+	response := ProductImportResponse{ProductImport: *job, Rows: []models.ProductImportRow{}}
+	json.Unmarshal([]byte(job.Report), &response.Rows)
+	if job.TotalRows > 0 {
+		response.Progress = job.ProcessedRows * 100 / job.TotalRows
+	}
+	return response
+}
+
+// runProductImport creates each row's listing, saving progress after every row so it can be polled
+func runProductImport(job models.ProductImport, user models.User, rows []importRow, images map[string]*zip.File) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Product import %s panicked: %v", job.ID, r)
+			config.DB.Model(&job).Updates(map[string]interface{}{"status": "failed", "error": "Import stopped unexpectedly"})
+		}
+	}()
+
+	config.DB.Model(&job).Update("status", "processing")
+
+	report := make([]models.ProductImportRow, 0, len(rows))
+	for i, row := range rows {
+		result := importListing(row, user, images)
+		result.Row = i + 1
+		report = append(report, result)
+
+		if result.Status == "created" {
+			job.SucceededRows++
+		} else {
+			job.FailedRows++
+		}
+		job.ProcessedRows++
+		reportJSON, _ := json.Marshal(report)
+		config.DB.Model(&job).Updates(map[string]interface{}{
+			"processed_rows": job.ProcessedRows,
+			"succeeded_rows": job.SucceededRows,
+			"failed_rows":    job.FailedRows,
+			"report":         string(reportJSON),
+		})
+	}
+
+	now := time.Now()
+	config.DB.Model(&job).Updates(map[string]interface{}{"status": "completed", "completed_at": now})
+	log.Printf("Product import %s finished: %d created, %d failed", job.ID, job.SucceededRows, job.FailedRows)
+
+	notify(NotificationEvent{
+		UserID: user.ID,
+		Type:   NotificationEventListingImportCompleted,
+		Title:  "Your import is done",
+		Body:   fmt.Sprintf("%d of %d listings from %s were created", job.SucceededRows, job.TotalRows, job.Filename),
+		Link:   "/profile",
+	})
+}
+
+// importListing validates and creates a single row with the same rules as CreateProduct
+func importListing(row importRow, user models.User, images map[string]*zip.File) models.ProductImportRow {
+	result := models.ProductImportRow{Title: strings.TrimSpace(row.Input.Title), Status: "failed"}
+
+	product, tags, publishNow, err := row.Input.newListing()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Title = product.Title
+
+	imageURLs := make([]string, 0, len(row.Images))
+	for _, ref := range row.Images {
+		url, err := importImage(ref, images)
+		if err != nil {
+			result.Error = fmt.Sprintf("Image %q: %v", ref, err)
+			return result
+		}
+		imageURLs = append(imageURLs, url)
+	}
+	imagesJSON, _ := json.Marshal(imageURLs)
+	product.Images = string(imagesJSON)
+	product.SellerID = user.ID
+	product.CollegeID = user.CollegeID
+
+	if err := createListing(config.DB, product, tags, publishNow); err != nil {
+		log.Printf("Failed to create imported product: %v", err)
+		result.Error = "Failed to create product"
+		return result
+	}
+
+	result.Status = "created"
+	result.ProductID = &product.ID
+	return result
+}
+
+// importImage fetches an image from a URL or the uploaded zip and stores it like a form upload,
+// including the content safety check
+func importImage(ref string, images map[string]*zip.File) (string, error) {
+	var data []byte
+	if strings.HasPrefix(ref, "http://") {
+		return "", fmt.Errorf("image URLs must use https")
+	}
+	if strings.HasPrefix(ref, "https://") {
+		resp, err := importImageClient.Get(ref)
+		if err != nil {
+			return "", fmt.Errorf("download failed")
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("download failed with %s", resp.Status)
+		}
+		if data, err = io.ReadAll(io.LimitReader(resp.Body, maxImportImageSize+1)); err != nil {
+			return "", fmt.Errorf("download failed")
+		}
+	} else {
+		entry, ok := images[strings.ToLower(path.Base(ref))]
+		if !ok {
+			return "", fmt.Errorf("not found in the images zip")
+		}
+		src, err := entry.Open()
+		if err != nil {
+			return "", fmt.Errorf("unreadable in the images zip")
+		}
+		defer src.Close()
+		if data, err = io.ReadAll(io.LimitReader(src, maxImportImageSize+1)); err != nil {
+			return "", fmt.Errorf("unreadable in the images zip")
+		}
+	}
+
+	if len(data) > maxImportImageSize {
+		return "", fmt.Errorf("larger than %d MB", maxImportImageSize>>20)
+	}
+	contentType := http.DetectContentType(data)
+	ext, ok := map[string]string{"image/jpeg": ".jpg", "image/png": ".png", "image/gif": ".gif", "image/webp": ".webp"}[contentType]
+	if !ok {
+		return "", fmt.Errorf("not a JPEG, PNG, GIF or WebP image")
+	}
+
+	return uploadImageBytesWithSafety(uuid.New().String()+ext, data)
+}
+
+// parseImportCSV reads listings from a CSV with a header row; images are separated by "|"
+func parseImportCSV(data []byte) ([]importRow, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("Invalid CSV: %v", err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	header := make([]string, len(records[0]))
+	for i, name := range records[0] {
+		header[i] = strings.ToLower(strings.TrimSpace(name))
+	}
+	hasColumn := map[string]bool{}
+	for _, name := range header {
+		hasColumn[name] = true
+	}
+	// title may be left out for textbooks, which take it from the ISBN
+	for _, required := range []string{"price", "condition", "category"} {
+		if !hasColumn[required] {
+			return nil, fmt.Errorf("CSV is missing the %q column", required)
+		}
+	}
+
+	rows := make([]importRow, 0, len(records)-1)
+	for _, record := range records[1:] {
+		fields := map[string]string{}
+		empty := true
+		for i, value := range record {
+			if i < len(header) {
+				fields[header[i]] = strings.TrimSpace(value)
+				empty = empty && fields[header[i]] == ""
+			}
+		}
+		if empty {
+			continue
+		}
+		rows = append(rows, importRow{
+			Input: ProductInput{
+				Title:       fields["title"],
+				Price:       fields["price"],
+				Description: fields["description"],
+				Condition:   fields["condition"],
+				Category:    fields["category"],
+				Attributes:  fields["attributes"],
+				Tags:        fields["tags"],
+				Draft:       strings.EqualFold(fields["draft"], "true"),
+				PublishAt:   fields["publish_at"],
+			},
+			Images: splitImportImages(fields["images"]),
+		})
+	}
+	return rows, nil
+}
+
+// parseImportJSON reads listings from a JSON array of objects; attributes may be an object,
+// tags and images arrays or strings, price a number or a string
+func parseImportJSON(data []byte) ([]importRow, error) {
+	var records []map[string]interface{}
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("Import JSON must be an array of listing objects")
+	}
+
+	rows := make([]importRow, 0, len(records))
+	for _, record := range records {
+		row := importRow{
+			Input: ProductInput{
+				Title:       importString(record["title"]),
+				Price:       importString(record["price"]),
+				Description: importString(record["description"]),
+				Condition:   importString(record["condition"]),
+				Category:    importString(record["category"]),
+				Attributes:  importString(record["attributes"]),
+				Tags:        importString(record["tags"]),
+				Draft:       importString(record["draft"]) == "true",
+				PublishAt:   importString(record["publish_at"]),
+			},
+		}
+		switch images := record["images"].(type) {
+		case []interface{}:
+			for _, image := range images {
+				if ref := strings.TrimSpace(importString(image)); ref != "" {
+					row.Images = append(row.Images, ref)
+				}
+			}
+		case string:
+			row.Images = splitImportImages(images)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// importString renders a JSON value as the string the create form would have sent
+func importString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case map[string]interface{}, []interface{}:
+		encoded, _ := json.Marshal(v)
+		return string(encoded)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func splitImportImages(value string) []string {
+	var images []string
+	for _, ref := range strings.Split(value, "|") {
+		if ref = strings.TrimSpace(ref); ref != "" {
+			images = append(images, ref)
+		}
+	}
+	return images
+}
+
+// readImportZip indexes the images in an uploaded zip by lowercased base name
+func readImportZip(file *multipart.FileHeader) (map[string]*zip.File, error) {
+	data, err := readFormFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read images zip")
+	}
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("Images must be uploaded as a zip archive")
+	}
+
+	images := make(map[string]*zip.File, len(archive.File))
+	for _, entry := range archive.File {
+		name := path.Base(entry.Name)
+		if entry.FileInfo().IsDir() || strings.HasPrefix(name, ".") || strings.HasPrefix(entry.Name, "__MACOSX/") {
+			continue
+		}
+		images[strings.ToLower(name)] = entry
+	}
+	return images, nil
+}
+
+func readFormFile(file *multipart.FileHeader) ([]byte, error) {
+	src, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+	return io.ReadAll(src)
+}
+
+// failStalledImports marks imports that stopped making progress (e.g. the server restarted) as failed
+func failStalledImports() {
+	result := config.DB.Model(&models.ProductImport{}).
+		Where("status IN ? AND updated_at < ?", []string{"pending", "processing"}, time.Now().Add(-importStalledAfter)).
+		Updates(map[string]interface{}{
+			"status": "failed",
+			"error":  "Import was interrupted; rows without a product_id in the report were not created",
+		})
+	if result.Error != nil {
+		log.Printf("Failed to mark stalled imports: %v", result.Error)
+	}
+}
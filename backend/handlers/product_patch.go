@@ -0,0 +1,322 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"marketplace-backend/config"
+	"marketplace-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	maxProductTitleLength       = 120
+	maxProductDescriptionLength = 5000
+	maxProductImages            = 10
+)
+
+// productConditions are the accepted values of Product.Condition
+var productConditions = []string{"New", "Like New", "Good", "Fair", "For Parts"}
+
+// ProductPatchRequest is a JSON Merge Patch (RFC 7396) of the editable product fields.
+// Absent fields are left alone and null clears a field; attributes are merged key by key.
+type ProductPatchRequest struct {
+	Title       *string                `json:"title"`
+	Description *string                `json:"description"`
+	Price       *float64               `json:"price"`
+	Condition   *string                `json:"condition"`
+	Category    *string                `json:"category"` // name, slug or ID
+	Images      *[]string              `json:"images"`
+	Tags        *[]string              `json:"tags"`
+	Attributes  map[string]interface{} `json:"attributes"`
+	Status      *string                `json:"status"`
+
+	present map[string]bool // every top-level key in the patch, including nulls
+}
+
+// patchableProductFields lists the keys a patch may contain; anything else (seller_id, college_id, ...) is rejected
+var patchableProductFields = map[string]bool{
+	"title": true, "description": true, "price": true, "condition": true, "category": true,
+	"images": true, "tags": true, "attributes": true, "status": true,
+}
+
+// PatchProduct applies a merge patch to a product the caller owns and returns the updated ProductDTO.
+// When If-Match is sent it must match the product's current ETag.
+func PatchProduct(c *gin.Context) {
+	product, ok := loadOwnProduct(c)
+	if !ok {
+		return
+	}
+
+	if ifMatch := c.GetHeader("If-Match"); ifMatch != "" && !etagMatches(ifMatch, productETag(product)) {
+		c.Header("ETag", productETag(product))
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "Product was modified by another request; reload it and try again"})
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+		return
+	}
+	patch, err := parseProductPatch(body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updates, tags, fieldErrors := patch.apply(product)
+	if len(fieldErrors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product update", "fields": fieldErrors})
+		return
+	}
+
+	oldPrice := product.Price
+
+	// The updated_at guard turns a concurrent write between load and save into a 412 as well
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(product).Where("updated_at = ?", product.UpdatedAt).Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errProductModified
+		}
+		if tags != nil {
+			return config.SetProductTags(tx, product, *tags)
+		}
+		return nil
+	})
+	if err == errProductModified {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "Product was modified by another request; reload it and try again"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update product"})
+		return
+	}
+
+	config.DB.Preload("Seller").Preload("College").First(product, product.ID)
+
+	if product.Price != oldPrice {
+		go handlePriceChange(*product, oldPrice)
+	}
+
+	responseDTO := ProductDTOFromModel(product)
+	responseDTO.PreviousPrice = previousPriceFor(product)
+	c.Header("ETag", productETag(product))
+	c.JSON(http.StatusOK, responseDTO)
+}
+
+var errProductModified = fmt.Errorf("product modified concurrently")
+
+// parseProductPatch decodes a merge patch, rejecting unknown and read-only fields
+func parseProductPatch(body []byte) (*ProductPatchRequest, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil || raw == nil {
+		return nil, fmt.Errorf("Request body must be a JSON object")
+	}
+
+	patch := &ProductPatchRequest{present: make(map[string]bool, len(raw))}
+	for key := range raw {
+		if !patchableProductFields[key] {
+			return nil, fmt.Errorf("Field %q cannot be changed", key)
+		}
+		patch.present[key] = true
+	}
+	if err := json.Unmarshal(body, patch); err != nil {
+		return nil, fmt.Errorf("Invalid field type: %v", err)
+	}
+	if raw, ok := raw["attributes"]; ok && string(raw) != "null" && patch.Attributes == nil {
+		return nil, fmt.Errorf("attributes must be an object")
+	}
+	return patch, nil
+}
+
+// apply validates the patch against the product and returns the column updates and, when tags change, the new tag list
+func (p *ProductPatchRequest) apply(product *models.Product) (map[string]interface{}, *[]string, map[string]string) {
+	updates := map[string]interface{}{"updated_at": time.Now()}
+	fieldErrors := map[string]string{}
+
+	if p.present["title"] {
+		title := ""
+		if p.Title != nil {
+			title = strings.TrimSpace(*p.Title)
+		}
+		switch {
+		case title == "":
+			fieldErrors["title"] = "Title is required"
+		case len([]rune(title)) > maxProductTitleLength:
+			fieldErrors["title"] = fmt.Sprintf("Title must be at most %d characters", maxProductTitleLength)
+		default:
+			updates["title"] = title
+		}
+	}
+
+	if p.present["description"] {
+		description := ""
+		if p.Description != nil {
+			description = strings.TrimSpace(*p.Description)
+		}
+		if len([]rune(description)) > maxProductDescriptionLength {
+			fieldErrors["description"] = fmt.Sprintf("Description must be at most %d characters", maxProductDescriptionLength)
+		} else {
+			updates["description"] = description
+		}
+	}
+
+	if p.present["price"] {
+		switch {
+		case p.Price == nil:
+			fieldErrors["price"] = "Price is required"
+		case *p.Price < 0 || math.IsNaN(*p.Price) || math.IsInf(*p.Price, 0):
+			fieldErrors["price"] = "Price must be zero or more"
+		default:
+			updates["price"] = math.Round(*p.Price*100) / 100
+		}
+	}
+
+	if p.present["condition"] {
+		condition := ""
+		if p.Condition != nil {
+			condition = canonicalCondition(*p.Condition)
+		}
+		if condition == "" {
+			fieldErrors["condition"] = "Condition must be one of: " + strings.Join(productConditions, ", ")
+		} else {
+			updates["condition"] = condition
+		}
+	}
+
+	if p.present["images"] {
+		images := []string{}
+		if p.Images != nil {
+			images = *p.Images
+		}
+		if len(images) > maxProductImages {
+			fieldErrors["images"] = fmt.Sprintf("At most %d images are allowed", maxProductImages)
+		} else if bad := firstInvalidImageURL(images); bad != "" {
+			fieldErrors["images"] = fmt.Sprintf("%q is not an http(s) URL", bad)
+		} else {
+			imagesJSON, _ := json.Marshal(images)
+			updates["images"] = string(imagesJSON)
+		}
+	}
+
+	var tags *[]string
+	if p.present["tags"] {
+		names := []string{}
+		if p.Tags != nil {
+			names = *p.Tags
+		}
+		tags = &names
+	}
+
+	if p.present["status"] && (p.Status == nil || *p.Status != product.Status) {
+		switch {
+		case p.Status == nil || *p.Status == "":
+			fieldErrors["status"] = "Status is required"
+		case lifecycleProductStatuses[*p.Status] || lifecycleProductStatuses[product.Status]:
+			fieldErrors["status"] = "Use the publish or relist endpoints to change a listing's lifecycle status"
+		case *p.Status != ProductStatusAvailable && *p.Status != ProductStatusRequested && *p.Status != ProductStatusSold:
+			fieldErrors["status"] = "Unknown status"
+		default:
+			updates["status"] = *p.Status
+			if *p.Status == ProductStatusSold {
+				updates["sold_at"] = time.Now()
+			} else {
+				updates["sold_at"] = nil
+			}
+		}
+	}
+
+	// Category and attributes are validated together against the (possibly new) category schema
+	if p.present["category"] || p.present["attributes"] {
+		categoryRef := product.Category
+		if product.CategoryID != nil {
+			categoryRef = product.CategoryID.String()
+		}
+		if p.present["category"] {
+			categoryRef = ""
+			if p.Category != nil {
+				categoryRef = strings.TrimSpace(*p.Category)
+			}
+		}
+
+		attributes, _ := parseProductAttributes(product.Attributes)
+		if attributes == nil || (p.present["attributes"] && p.Attributes == nil) {
+			attributes = map[string]interface{}{}
+		}
+		for key, value := range p.Attributes {
+			if value == nil {
+				delete(attributes, key)
+			} else {
+				attributes[key] = value
+			}
+		}
+
+		if categoryRef == "" {
+			fieldErrors["category"] = "Category is required"
+		} else if category := config.FindCategory(categoryRef); category == nil || !category.IsActive {
+			fieldErrors["category"] = fmt.Sprintf("Unknown category %q, see GET /api/categories for valid values", categoryRef)
+		} else if categoryModel, attributes, err := resolveProductCategory(categoryRef, attributes); err != nil {
+			fieldErrors["attributes"] = err.Error()
+		} else if isbn, courseCode, err := bookIdentifiers(attributes); err != nil {
+			fieldErrors["attributes"] = err.Error()
+		} else {
+			attributesJSON, _ := json.Marshal(attributes)
+			updates["category"] = categoryModel.Name
+			updates["category_id"] = categoryModel.ID
+			updates["attributes"] = string(attributesJSON)
+			updates["isbn"] = isbn
+			updates["course_code"] = courseCode
+		}
+	}
+
+	return updates, tags, fieldErrors
+}
+
+// canonicalCondition matches a condition case-insensitively and returns its canonical spelling, or ""
+func canonicalCondition(condition string) string {
+	for _, known := range productConditions {
+		if strings.EqualFold(strings.TrimSpace(condition), known) {
+			return known
+		}
+	}
+	return ""
+}
+
+func firstInvalidImageURL(images []string) string {
+	for _, image := range images {
+		u, err := url.Parse(image)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return image
+		}
+	}
+	return ""
+}
+
+// productETag identifies the current version of a product; it changes on every update
+func productETag(product *models.Product) string {
+	return `"` + product.ID.String()[:8] + "-" + strconv.FormatInt(product.UpdatedAt.UnixNano(), 36) + `"`
+}
+
+// etagMatches checks an If-Match header, which may list several ETags or be "*"
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
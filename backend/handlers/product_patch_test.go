@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+
+	"marketplace-backend/models"
+)
+
+func TestParseProductPatch(t *testing.T) {
+	tests := []struct {
+		body        string
+		wantPresent []string
+		wantErr     bool
+	}{
+		{`{}`, nil, false},
+		{`{"title":"Desk lamp","price":12.5}`, []string{"title", "price"}, false},
+		{`{"description":null,"attributes":null}`, []string{"description", "attributes"}, false},
+		{`{"attributes":{"isbn":null}}`, []string{"attributes"}, false},
+		{`{"seller_id":"00000000-0000-0000-0000-000000000000"}`, nil, true},
+		{`{"price":"cheap"}`, nil, true},
+		{`{"attributes":[1,2]}`, nil, true},
+		{`[]`, nil, true},
+		{`null`, nil, true},
+		{`not json`, nil, true},
+	}
+	for _, tt := range tests {
+		patch, err := parseProductPatch([]byte(tt.body))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseProductPatch(%s) error = %v, want error %v", tt.body, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if len(patch.present) != len(tt.wantPresent) {
+			t.Errorf("parseProductPatch(%s) present = %v, want %v", tt.body, patch.present, tt.wantPresent)
+		}
+		for _, key := range tt.wantPresent {
+			if !patch.present[key] {
+				t.Errorf("parseProductPatch(%s) is missing present key %q", tt.body, key)
+			}
+		}
+	}
+}
+
+func TestProductPatchApply(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		wantUpdates map[string]interface{}
+		wantTags    []string // nil when tags are left alone
+		wantErrors  []string
+	}{
+		{"empty patch changes nothing", `{}`, map[string]interface{}{}, nil, nil},
+		{"title is trimmed", `{"title":"  Desk lamp "}`, map[string]interface{}{"title": "Desk lamp"}, nil, nil},
+		{"null title is rejected", `{"title":null}`, map[string]interface{}{}, nil, []string{"title"}},
+		{"null description clears it", `{"description":null}`, map[string]interface{}{"description": ""}, nil, nil},
+		{"price is rounded to cents", `{"price":9.999}`, map[string]interface{}{"price": 10.0}, nil, nil},
+		{"negative price", `{"price":-1}`, map[string]interface{}{}, nil, []string{"price"}},
+		{"condition is canonicalized", `{"condition":"like new"}`, map[string]interface{}{"condition": "Like New"}, nil, nil},
+		{"unknown condition", `{"condition":"Mint"}`, map[string]interface{}{}, nil, []string{"condition"}},
+		{"null images clears them", `{"images":null}`, map[string]interface{}{"images": "[]"}, nil, nil},
+		{"non-http image", `{"images":["ftp://example.com/a.jpg"]}`, map[string]interface{}{}, nil, []string{"images"}},
+		{"null tags clears them", `{"tags":null}`, map[string]interface{}{}, []string{}, nil},
+		{"tags replace the list", `{"tags":["desk","lamp"]}`, map[string]interface{}{}, []string{"desk", "lamp"}, nil},
+		{"same status is a no-op", `{"status":"available"}`, map[string]interface{}{}, nil, nil},
+		{"mark as requested", `{"status":"requested"}`, map[string]interface{}{"status": "requested", "sold_at": nil}, nil, nil},
+		{"lifecycle status is refused", `{"status":"archived"}`, map[string]interface{}{}, nil, []string{"status"}},
+		{"errors are collected per field", `{"title":"","price":-1,"status":"gone"}`, map[string]interface{}{}, nil, []string{"title", "price", "status"}},
+	}
+	for _, tt := range tests {
+		patch, err := parseProductPatch([]byte(tt.body))
+		if err != nil {
+			t.Fatalf("%s: parseProductPatch: %v", tt.name, err)
+		}
+		product := &models.Product{Title: "Lamp", Price: 15, Condition: "Good", Status: ProductStatusAvailable}
+
+		updates, tags, fieldErrors := patch.apply(product)
+
+		if _, ok := updates["updated_at"]; !ok {
+			t.Errorf("%s: updated_at not set", tt.name)
+		}
+		delete(updates, "updated_at")
+		if !reflect.DeepEqual(updates, tt.wantUpdates) {
+			t.Errorf("%s: updates = %v, want %v", tt.name, updates, tt.wantUpdates)
+		}
+		if (tags == nil) != (tt.wantTags == nil) || (tags != nil && !reflect.DeepEqual(*tags, tt.wantTags)) {
+			t.Errorf("%s: tags = %v, want %v", tt.name, tags, tt.wantTags)
+		}
+		if len(fieldErrors) != len(tt.wantErrors) {
+			t.Errorf("%s: field errors = %v, want errors on %v", tt.name, fieldErrors, tt.wantErrors)
+		}
+		for _, field := range tt.wantErrors {
+			if _, ok := fieldErrors[field]; !ok {
+				t.Errorf("%s: no error for %q in %v", tt.name, field, fieldErrors)
+			}
+		}
+	}
+}
+
+func TestETagMatches(t *testing.T) {
+	const etag = `"1a2b3c4d-abc123"`
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{`"1a2b3c4d-abc123"`, true},
+		{`*`, true},
+		{`W/"1a2b3c4d-abc123"`, true},
+		{`"other", "1a2b3c4d-abc123"`, true},
+		{`"other",W/"1a2b3c4d-abc123"`, true},
+		{`"other"`, false},
+		{`1a2b3c4d-abc123`, false},
+		{`"1a2b3c4d-abc12"`, false},
+	}
+	for _, tt := range tests {
+		if got := etagMatches(tt.header, etag); got != tt.want {
+			t.Errorf("etagMatches(%s) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
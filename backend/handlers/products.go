@@ -10,20 +10,27 @@ import (
 	"net/http"
 	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 	"marketplace-backend/config"
 	"marketplace-backend/models"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
-// GetProducts returns all products for a college
+// GetProducts returns all products for a college, optionally filtered by
+// ?q=&category=&max_price=&condition=
 func GetProducts(c *gin.Context) {
 	var products []models.Product
 	
 	// For now, get all products (later filter by college)
-	result := config.DB.Preload("Seller").Preload("College").Find(&products)
+	query := productFilterFromQuery(c).Apply(config.DB.Model(&models.Product{}))
+	query = query.Scopes(visibleListings(c)).Where("products.status IN ?", publicProductStatuses).
+		Order("COALESCE(products.published_at, products.created_at) DESC")
+	result := query.Preload("Seller").Preload("College").Find(&products)
 	if result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch products"})
 		return
@@ -59,9 +66,17 @@ func GetProduct(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
 		return
 	}
+	if !productVisibleTo(c, &product) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+
+	recordProductView(c, &product)
 
 	// Convert to DTO to include seller information
 	responseDTO := ProductDTOFromModel(&product)
+	responseDTO.PreviousPrice = previousPriceFor(&product)
+	c.Header("ETag", productETag(&product))
 	c.JSON(http.StatusOK, responseDTO)
 }
 
@@ -75,24 +90,33 @@ func CreateProduct(c *gin.Context) {
 	}
 
 	// Get form values using Gin's methods
-	title := c.PostForm("title")
-	priceStr := c.PostForm("price")
-	description := c.PostForm("description")
-	condition := c.PostForm("condition")
-	category := c.PostForm("category")
-	tagsStr := c.PostForm("tags")
+	input := ProductInput{
+		Title:       c.PostForm("title"),
+		Price:       c.PostForm("price"),
+		Description: c.PostForm("description"),
+		Condition:   c.PostForm("condition"),
+		Category:    c.PostForm("category"),
+		Attributes:  c.PostForm("attributes"),
+		Tags:        c.PostForm("tags"),
+		Draft:       c.PostForm("draft") == "true",
+		PublishAt:   c.PostForm("publish_at"),
+	}
+	if categoryID := c.PostForm("category_id"); categoryID != "" {
+		input.Category = categoryID
+	}
 
 	log.Println("--- Received form data ---")
-	log.Println("Title:", title)
-	log.Println("Price:", priceStr)
-	log.Println("Description:", description)
-	log.Println("Condition:", condition)
-	log.Println("Category:", category)
-	log.Println("Tags:", tagsStr)
+	log.Println("Title:", input.Title)
+	log.Println("Price:", input.Price)
+	log.Println("Description:", input.Description)
+	log.Println("Condition:", input.Condition)
+	log.Println("Category:", input.Category)
+	log.Println("Tags:", input.Tags)
 	log.Println("--------------------------")
-	price, err := strconv.ParseFloat(priceStr, 64)
+
+	product, tags, publishNow, err := input.newListing()
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid price format"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -128,6 +152,7 @@ func CreateProduct(c *gin.Context) {
 	log.Printf("✅ All images approved and uploaded: %v", approvedImages)
 
 	imagesJSON, _ := json.Marshal(imageURLs)
+	product.Images = string(imagesJSON)
 
 	// Get user's college
 	var user models.User
@@ -135,33 +160,128 @@ func CreateProduct(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "User not found"})
 		return
 	}
+	product.SellerID = user.ID
+	product.CollegeID = user.CollegeID
 
-	product := models.Product{
-		Title:       title,
-		Price:       price,
-		Description: description,
-		Images:      string(imagesJSON),
-		Condition:   condition,
-		Category:    category,
-		Tags:        tagsStr, // Storing as a simple string for now
-		Status:      "available",
-		SellerID:    user.ID,
-		CollegeID:   user.CollegeID,
-	}
-
-	result := config.DB.Create(&product)
-	if result.Error != nil {
+	if err := createListing(config.DB, product, tags, publishNow); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create product"})
 		return
 	}
 
 	// Preload relationships for response
-	config.DB.Preload("Seller").Preload("College").First(&product, product.ID)
+	config.DB.Preload("Seller").Preload("College").First(product, product.ID)
 
-	responseDTO := ProductDTOFromModel(&product)
+	responseDTO := ProductDTOFromModel(product)
 	c.JSON(http.StatusCreated, responseDTO)
 }
 
+// ProductInput is a new listing as submitted by the create form or a bulk import row
+type ProductInput struct {
+	Title       string `json:"title"`
+	Price       string `json:"price"`
+	Description string `json:"description"`
+	Condition   string `json:"condition"`
+	Category    string `json:"category"`   // name, slug or ID
+	Attributes  string `json:"attributes"` // JSON object
+	Tags        string `json:"tags"`       // CSV or JSON array
+	Draft       bool   `json:"draft"`
+	PublishAt   string `json:"publish_at"` // RFC 3339
+}
+
+// newListing validates the input and builds the product (without images, seller or college)
+// along with its tags and whether it should go live right away
+func (in ProductInput) newListing() (*models.Product, []string, bool, error) {
+	price, err := strconv.ParseFloat(strings.TrimSpace(in.Price), 64)
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("Invalid price format")
+	}
+	if price < 0 {
+		return nil, nil, false, fmt.Errorf("Price must be zero or more")
+	}
+	condition := canonicalCondition(in.Condition)
+	if condition == "" {
+		return nil, nil, false, fmt.Errorf("Condition must be one of: %s", strings.Join(productConditions, ", "))
+	}
+
+	// Listings go live immediately unless saved as a draft or scheduled with publish_at (RFC 3339)
+	status := ProductStatusDraft
+	var publishAt *time.Time
+	if raw := strings.TrimSpace(in.PublishAt); raw != "" && !in.Draft {
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return nil, nil, false, fmt.Errorf("publish_at must be an RFC 3339 timestamp")
+		}
+		if t.After(time.Now()) {
+			status, publishAt = ProductStatusScheduled, &t
+		}
+	}
+	publishNow := !in.Draft && publishAt == nil
+
+	attributes, err := parseProductAttributes(in.Attributes)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	categoryModel, attributes, err := resolveProductCategory(in.Category, attributes)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	isbn, courseCode, err := bookIdentifiers(attributes)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	title := strings.TrimSpace(in.Title)
+	if title == "" {
+		title = bookTitleForISBN(isbn)
+	}
+	if title == "" {
+		return nil, nil, false, fmt.Errorf("Title is required")
+	}
+	attributesJSON, _ := json.Marshal(attributes)
+
+	product := &models.Product{
+		Title:       title,
+		Price:       price,
+		Description: in.Description,
+		Images:      "[]",
+		Condition:   condition,
+		Category:    categoryModel.Name,
+		CategoryID:  &categoryModel.ID,
+		Attributes:  string(attributesJSON),
+		ISBN:        isbn,
+		CourseCode:  courseCode,
+		Tags:        "[]", // filled in by SetProductTags
+		Status:      status,
+		PublishAt:   publishAt,
+	}
+	return product, config.ParseTags(in.Tags), publishNow, nil
+}
+
+// createListing saves a validated product with its tags, records its first price and publishes it when asked.
+// Publishing is part of the same transaction, so a listing meant to go live is never left behind as a draft.
+func createListing(db *gorm.DB, product *models.Product, tags []string, publishNow bool) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(product).Error; err != nil {
+			return err
+		}
+		if err := config.SetProductTags(tx, product, tags); err != nil {
+			return err
+		}
+		// Publishing also alerts users whose saved searches match the new listing
+		if publishNow {
+			return publishListing(tx, product)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := recordPrice(db, product.ID, product.Price); err != nil {
+		log.Printf("Failed to record initial price for product %s: %v", product.ID, err)
+	}
+	return nil
+}
+
 // uploadImageToAzureWithSafety checks content safety before uploading to blob storage
 func uploadImageToAzureWithSafety(file *multipart.FileHeader) (string, error) {
 	if config.BlobClient == nil {
@@ -181,8 +301,17 @@ func uploadImageToAzureWithSafety(file *multipart.FileHeader) (string, error) {
 		return "", err
 	}
 
+	return uploadImageBytesWithSafety(file.Filename, buffer)
+}
+
+// uploadImageBytesWithSafety checks an image already read into memory and uploads it to blob storage
+func uploadImageBytesWithSafety(filename string, buffer []byte) (string, error) {
+	if config.BlobClient == nil {
+		return "", fmt.Errorf("Azure Blob Storage client is not initialized")
+	}
+
 	// === CONTENT SAFETY CHECK ===
-	log.Printf("Checking content safety for image: %s", file.Filename)
+	log.Printf("Checking content safety for image: %s", filename)
 	isSafe, err := config.CheckImageSafety(buffer)
 	if err != nil {
 		log.Printf("Content safety check failed: %v", err)
@@ -190,14 +319,14 @@ func uploadImageToAzureWithSafety(file *multipart.FileHeader) (string, error) {
 	}
 
 	if !isSafe {
-		log.Printf("Image rejected due to inappropriate content: %s", file.Filename)
+		log.Printf("Image rejected due to inappropriate content: %s", filename)
 		return "", fmt.Errorf("image contains inappropriate content and cannot be uploaded")
 	}
 
-	log.Printf("Image approved by content safety: %s", file.Filename)
+	log.Printf("Image approved by content safety: %s", filename)
 
 	// Generate a unique file name
-	ext := filepath.Ext(file.Filename)
+	ext := filepath.Ext(filename)
 	fileName := fmt.Sprintf("product-images/%s%s", uuid.New().String(), ext)
 
 	// Upload to Azure Blob Storage (only if content is safe)
@@ -210,7 +339,7 @@ func uploadImageToAzureWithSafety(file *multipart.FileHeader) (string, error) {
 
 	// Construct the public URL
 	url := fmt.Sprintf("%s/%s/%s", config.GetBlobContainerURL(), containerName, fileName)
-	log.Printf("Image uploaded successfully: %s -> %s", file.Filename, url)
+	log.Printf("Image uploaded successfully: %s -> %s", filename, url)
 	return url, nil
 }
 
@@ -279,15 +408,105 @@ func UpdateProduct(c *gin.Context) {
 		return
 	}
 
-	var updateData models.Product
+	// Attributes and tags arrive as JSON values rather than the model's string columns
+	var updateData struct {
+		models.Product
+		Attributes map[string]interface{} `json:"attributes"`
+		Tags       json.RawMessage        `json:"tags"`
+	}
 	if err := c.ShouldBindJSON(&updateData); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	// Only the fields PUT has always accepted are taken from the body, and they're validated like a PATCH;
+	// zero values are still ignored. PATCH /api/products/:id is the stricter alternative
+	patch := &ProductPatchRequest{present: map[string]bool{}}
+	if updateData.Title != "" {
+		patch.Title, patch.present["title"] = &updateData.Title, true
+	}
+	if updateData.Description != "" {
+		patch.Description, patch.present["description"] = &updateData.Description, true
+	}
+	if updateData.Price != 0 {
+		patch.Price, patch.present["price"] = &updateData.Price, true
+	}
+	if updateData.Condition != "" {
+		patch.Condition, patch.present["condition"] = &updateData.Condition, true
+	}
+	if updateData.Images != "" {
+		var images []string
+		if err := json.Unmarshal([]byte(updateData.Images), &images); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "images must be a JSON array of URLs"})
+			return
+		}
+		patch.Images, patch.present["images"] = &images, true
+	}
+	if updateData.Status != "" {
+		patch.Status, patch.present["status"] = &updateData.Status, true
+	}
+	updates, _, fieldErrors := patch.apply(&product)
+	if len(fieldErrors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product update", "fields": fieldErrors})
+		return
+	}
+
+	// Re-validate the category whenever it or the attributes change
+	if updateData.Category != "" || updateData.CategoryID != nil || updateData.Attributes != nil {
+		categoryRef := product.Category
+		if product.CategoryID != nil {
+			categoryRef = product.CategoryID.String()
+		}
+		if updateData.CategoryID != nil {
+			categoryRef = updateData.CategoryID.String()
+		} else if updateData.Category != "" {
+			categoryRef = updateData.Category
+		}
+
+		attributes := updateData.Attributes
+		if attributes == nil {
+			attributes, _ = parseProductAttributes(product.Attributes)
+		}
+
+		categoryModel, attributes, err := resolveProductCategory(categoryRef, attributes)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		isbn, courseCode, err := bookIdentifiers(attributes)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		attributesJSON, _ := json.Marshal(attributes)
+		updates["category"] = categoryModel.Name
+		updates["category_id"] = categoryModel.ID
+		updates["attributes"] = string(attributesJSON)
+		updates["isbn"] = isbn
+		updates["course_code"] = courseCode
+	}
+
+	var tags []string
+	if len(updateData.Tags) > 0 && string(updateData.Tags) != "null" {
+		if tags, err = tagsFromJSON(updateData.Tags); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	oldPrice := product.Price
+
 	// Update only provided fields
-	result = config.DB.Model(&product).Updates(updateData)
-	if result.Error != nil {
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&product).Updates(updates).Error; err != nil {
+			return err
+		}
+		if tags != nil {
+			return config.SetProductTags(tx, &product, tags)
+		}
+		return nil
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update product"})
 		return
 	}
@@ -295,10 +514,13 @@ func UpdateProduct(c *gin.Context) {
 	// Preload relationships for response
 	config.DB.Preload("Seller").Preload("College").First(&product, product.ID)
 
-	c.JSON(http.StatusOK, product)
+	// Record the new price and alert favoriters on genuine drops
+	go handlePriceChange(product, oldPrice)
+
+	c.JSON(http.StatusOK, ListingDTOFromModel(&product))
 }
 
-// DeleteProduct deletes a product
+// DeleteProduct soft-deletes a product; the seller can restore it within the restore window
 func DeleteProduct(c *gin.Context) {
 	// Get authenticated user ID
 	userID, exists := c.Get("userID")
@@ -321,17 +543,43 @@ func DeleteProduct(c *gin.Context) {
 		return
 	}
 
-	// Check if user owns this product
-	if product.SellerID != userID {
+	// Check if user owns this product (admins may remove any listing)
+	if product.SellerID != userID && !isAdminRequest(c) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "You can only delete your own products"})
 		return
 	}
 
-	result = config.DB.Delete(&product)
-	if result.Error != nil {
+	var declined []models.PurchaseRequest
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if declined, err = removeListing(tx, &product, userID.(uuid.UUID)); err != nil {
+			return err
+		}
+		return recordAudit(tx, c, AuditEntry{
+			Action:     AuditListingRemoved,
+			TargetType: "product",
+			TargetID:   product.ID,
+			Before:     gin.H{"status": product.Status, "deleted": false},
+			After:      gin.H{"deleted": true},
+		})
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete product"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Product deleted successfully"})
+	for _, request := range declined {
+		notify(NotificationEvent{
+			UserID: request.BuyerID,
+			Type:   NotificationEventPurchaseRequestDeclined,
+			Title:  "Purchase request declined",
+			Body:   fmt.Sprintf("%s is no longer available", product.Title),
+			Link:   "/chats",
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Product deleted successfully",
+		"restore_until": time.Now().AddDate(0, 0, config.ProductRestoreWindowDays()),
+	})
 }
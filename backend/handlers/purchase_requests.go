@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
+	"time"
 	"marketplace-backend/config"
 	"marketplace-backend/models"
 
@@ -9,22 +11,34 @@ import (
 	"github.com/google/uuid"
 )
 
-// GetPurchaseRequests returns all purchase requests for a user
+// GetPurchaseRequests returns the purchase requests the authenticated user sent or received
 func GetPurchaseRequests(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
 	var requests []models.PurchaseRequest
-	
-	// For now, get all requests (later filter by college/user)
-	result := config.DB.Preload("Product").Preload("Buyer").Preload("Seller").Find(&requests)
+	result := config.DB.Preload("Product", withDeleted).Preload("Buyer", withDeleted).Preload("Seller", withDeleted).
+		Where("buyer_id = ? OR seller_id = ?", userID, userID).
+		Find(&requests)
 	if result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch purchase requests"})
 		return
 	}
 
-	c.JSON(http.StatusOK, requests)
+	c.JSON(http.StatusOK, PurchaseRequestDTOsFromModels(requests))
 }
 
-// CreatePurchaseRequest creates a new purchase request and a corresponding chat
+// CreatePurchaseRequest creates a new purchase request from the authenticated user and a corresponding chat
 func CreatePurchaseRequest(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
 	var request models.PurchaseRequest
 	if err := c.ShouldBindJSON(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -37,6 +51,18 @@ func CreatePurchaseRequest(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
 		return
 	}
+	if lifecycleProductStatuses[product.Status] {
+		c.JSON(http.StatusConflict, gin.H{"error": "This listing is not live"})
+		return
+	}
+	// The buyer is the caller and the seller the listing's owner, whatever the body says
+	request.BuyerID = userID.(uuid.UUID)
+	request.SellerID = product.SellerID
+	if request.BuyerID == request.SellerID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot request your own listing"})
+		return
+	}
+
 	var buyer models.User
 	if err := config.DB.First(&buyer, request.BuyerID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Buyer not found"})
@@ -47,6 +73,10 @@ func CreatePurchaseRequest(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Seller not found"})
 		return
 	}
+	if usersBlocked(buyer.ID, seller.ID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot send a purchase request to this seller"})
+		return
+	}
 
 	tx := config.DB.Begin()
 
@@ -80,14 +110,28 @@ func CreatePurchaseRequest(c *gin.Context) {
 		return
 	}
 
+	notify(NotificationEvent{
+		UserID: seller.ID,
+		Type:   NotificationEventPurchaseRequestCreated,
+		Title:  "New purchase request",
+		Body:   fmt.Sprintf("%s wants to buy %s", buyer.Name, product.Title),
+		Link:   "/chats",
+	})
+
 	// Preload relationships for response
-	config.DB.Preload("Product").Preload("Buyer").Preload("Seller").First(&request, request.ID)
+	config.DB.Preload("Product", withDeleted).Preload("Buyer", withDeleted).Preload("Seller", withDeleted).First(&request, request.ID)
 
-	c.JSON(http.StatusCreated, request)
+	c.JSON(http.StatusCreated, PurchaseRequestDTOFromModel(&request))
 }
 
-// UpdatePurchaseRequest updates the status of a purchase request
+// UpdatePurchaseRequest lets the seller accept or decline a pending purchase request
 func UpdatePurchaseRequest(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
 	id := c.Param("id")
 	requestID, err := uuid.Parse(id)
 	if err != nil {
@@ -101,14 +145,22 @@ func UpdatePurchaseRequest(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Purchase request not found"})
 		return
 	}
+	if request.SellerID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the seller can accept or decline this request"})
+		return
+	}
 
 	var updateData struct {
-		Status string `json:"status" binding:"required"`
+		Status string `json:"status" binding:"required,oneof=accepted declined"`
 	}
 	if err := c.ShouldBindJSON(&updateData); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if request.Status != "pending" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Purchase request was already " + request.Status})
+		return
+	}
 
 	request.Status = updateData.Status
 
@@ -120,7 +172,8 @@ func UpdatePurchaseRequest(c *gin.Context) {
 
 	// If accepted, update product status to sold and update chat to be accepted
 	if updateData.Status == "accepted" {
-		config.DB.Model(&models.Product{}).Where("id = ?", request.ProductID).Update("status", "sold")
+		config.DB.Model(&models.Product{}).Where("id = ?", request.ProductID).
+			Updates(map[string]interface{}{"status": ProductStatusSold, "sold_at": time.Now()})
 		var chat models.Chat
 		config.DB.Where("purchase_request_id = ?", request.ID).First(&chat)
 		config.DB.Model(&chat).Update("is_accepted", true)
@@ -128,7 +181,26 @@ func UpdatePurchaseRequest(c *gin.Context) {
 	}
 
 	// Preload relationships for response
-	config.DB.Preload("Product").Preload("Buyer").Preload("Seller").First(&request, request.ID)
+	config.DB.Preload("Product", withDeleted).Preload("Buyer", withDeleted).Preload("Seller", withDeleted).First(&request, request.ID)
+
+	switch updateData.Status {
+	case "accepted":
+		notify(NotificationEvent{
+			UserID: request.BuyerID,
+			Type:   NotificationEventPurchaseRequestAccepted,
+			Title:  "Purchase request accepted",
+			Body:   fmt.Sprintf("%s accepted your request for %s", request.Seller.Name, request.Product.Title),
+			Link:   "/chats",
+		})
+	case "declined":
+		notify(NotificationEvent{
+			UserID: request.BuyerID,
+			Type:   NotificationEventPurchaseRequestDeclined,
+			Title:  "Purchase request declined",
+			Body:   fmt.Sprintf("%s declined your request for %s", request.Seller.Name, request.Product.Title),
+			Link:   "/product/" + request.ProductID.String(),
+		})
+	}
 
-	c.JSON(http.StatusOK, request)
+	c.JSON(http.StatusOK, PurchaseRequestDTOFromModel(&request))
 }
@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"marketplace-backend/config"
+	"marketplace-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const pushMessageTTL = 24 * time.Hour
+
+// pushEventTypes are the notification events delivered as Web Push messages
+var pushEventTypes = map[string]bool{
+	NotificationEventMessageCreated:          true,
+	NotificationEventPurchaseRequestAccepted: true,
+	NotificationEventPurchaseRequestDeclined: true,
+	NotificationEventPriceDrop:               true,
+	NotificationEventListingExpiring:         true,
+}
+
+// PushSubscriptionRequest mirrors the browser's PushSubscription.toJSON() output
+type PushSubscriptionRequest struct {
+	Endpoint       string `json:"endpoint" binding:"required,url"`
+	ExpirationTime *int64 `json:"expirationTime"`
+	Keys           struct {
+		P256dh string `json:"p256dh" binding:"required"`
+		Auth   string `json:"auth" binding:"required"`
+	} `json:"keys" binding:"required"`
+	DeviceName string `json:"device_name"`
+}
+
+// pushPayload is the JSON the service worker receives
+type pushPayload struct {
+	Type  string `json:"type"`
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	URL   string `json:"url"`
+}
+
+// GetVAPIDPublicKey returns the application server key for PushManager.subscribe
+func GetVAPIDPublicKey(c *gin.Context) {
+	key := config.GetVAPIDPublicKey()
+	if key == "" || config.Pusher == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Web Push is not configured"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"public_key": key})
+}
+
+// GetPushSubscriptions lists the caller's registered devices
+func GetPushSubscriptions(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var subscriptions []models.PushSubscription
+	if err := config.DB.Where("user_id = ?", userID).Order("created_at DESC").Find(&subscriptions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch push subscriptions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, subscriptions)
+}
+
+// CreatePushSubscription registers (or re-registers) a device for the caller
+func CreatePushSubscription(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req PushSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := config.ValidatePushEndpoint(req.Endpoint); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var expiresAt *time.Time
+	if req.ExpirationTime != nil {
+		t := time.UnixMilli(*req.ExpirationTime)
+		expiresAt = &t
+	}
+
+	// An endpoint belongs to one browser profile; if someone else logged in there, move it over
+	var subscription models.PushSubscription
+	status := http.StatusOK
+	if err := config.DB.Where("endpoint = ?", req.Endpoint).First(&subscription).Error; err != nil {
+		status = http.StatusCreated
+	}
+	subscription.UserID = userID.(uuid.UUID)
+	subscription.Endpoint = req.Endpoint
+	subscription.P256dh = req.Keys.P256dh
+	subscription.Auth = req.Keys.Auth
+	subscription.DeviceName = req.DeviceName
+	subscription.UserAgent = c.Request.UserAgent()
+	subscription.ExpiresAt = expiresAt
+
+	if err := config.DB.Save(&subscription).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save push subscription"})
+		return
+	}
+
+	c.JSON(status, subscription)
+}
+
+// DeletePushSubscription removes one of the caller's devices
+func DeletePushSubscription(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	subscriptionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid subscription ID"})
+		return
+	}
+
+	result := config.DB.Where("id = ? AND user_id = ?", subscriptionID, userID).Delete(&models.PushSubscription{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove push subscription"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Push subscription not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Push subscription removed successfully"})
+}
+
+// sendPushNotification delivers an event to every device of the user, pruning dead subscriptions
+func sendPushNotification(event NotificationEvent) {
+	if config.Pusher == nil {
+		return
+	}
+
+	var subscriptions []models.PushSubscription
+	if err := config.DB.Where("user_id = ?", event.UserID).Find(&subscriptions).Error; err != nil {
+		log.Printf("Failed to load push subscriptions for user %s: %v", event.UserID, err)
+		return
+	}
+	if len(subscriptions) == 0 {
+		return
+	}
+
+	payload, _ := json.Marshal(pushPayload{
+		Type:  event.Type,
+		Title: event.Title,
+		Body:  event.Body,
+		URL:   event.Link,
+	})
+
+	for _, sub := range subscriptions {
+		err := config.Pusher.Send(config.PushTarget{
+			Endpoint: sub.Endpoint,
+			P256dh:   sub.P256dh,
+			Auth:     sub.Auth,
+		}, payload, pushMessageTTL)
+
+		switch {
+		case errors.Is(err, config.ErrPushSubscriptionGone):
+			log.Printf("Pruning expired push subscription %s", sub.ID)
+			config.DB.Delete(&sub)
+		case err != nil:
+			log.Printf("Failed to push %s to subscription %s: %v", event.Type, sub.ID, err)
+		default:
+			config.DB.Model(&sub).Update("last_used_at", time.Now())
+		}
+	}
+}
+
+// pruneExpiredPushSubscriptions removes subscriptions past the expiration time the browser reported
+func pruneExpiredPushSubscriptions() {
+	result := config.DB.Where("expires_at IS NOT NULL AND expires_at < ?", time.Now()).Delete(&models.PushSubscription{})
+	if result.Error != nil {
+		log.Printf("Failed to prune push subscriptions: %v", result.Error)
+		return
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("Pruned %d expired push subscriptions", result.RowsAffected)
+	}
+}
+
+// fakePushMessage is a push request captured by the fake push service
+type fakePushMessage struct {
+	Headers    map[string]string `json:"headers"`
+	Body       string            `json:"body"` // base64 of the encrypted payload
+	ReceivedAt time.Time         `json:"received_at"`
+}
+
+var (
+	fakePushMu       sync.Mutex
+	fakePushMessages = map[string][]fakePushMessage{}
+)
+
+// FakePushServiceReceive acts as a push service endpoint for local and integration testing.
+// Subscriptions whose endpoint token is "gone" get a 410 so pruning can be exercised.
+func FakePushServiceReceive(c *gin.Context) {
+	token := c.Param("token")
+	if token == "gone" {
+		c.Status(http.StatusGone)
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	msg := fakePushMessage{
+		Headers: map[string]string{
+			"Authorization":    c.GetHeader("Authorization"),
+			"Content-Encoding": c.GetHeader("Content-Encoding"),
+			"TTL":              c.GetHeader("TTL"),
+		},
+		Body:       base64.StdEncoding.EncodeToString(body),
+		ReceivedAt: time.Now(),
+	}
+
+	fakePushMu.Lock()
+	fakePushMessages[token] = append(fakePushMessages[token], msg)
+	fakePushMu.Unlock()
+
+	c.Status(http.StatusCreated)
+}
+
+// FakePushServiceMessages returns what the fake push service received for a token
+func FakePushServiceMessages(c *gin.Context) {
+	fakePushMu.Lock()
+	messages := append([]fakePushMessage{}, fakePushMessages[c.Param("token")]...)
+	fakePushMu.Unlock()
+
+	c.JSON(http.StatusOK, messages)
+}
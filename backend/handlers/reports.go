@@ -0,0 +1,388 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"marketplace-backend/config"
+	"marketplace-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Report target types
+const (
+	ReportTargetProduct = "product"
+	ReportTargetUser    = "user"
+	ReportTargetMessage = "message"
+)
+
+// Report statuses
+const (
+	ReportStatusOpen      = "open"
+	ReportStatusResolved  = "resolved"
+	ReportStatusDismissed = "dismissed"
+)
+
+// reportReasons are the reason codes a report can give; "other" needs details
+var reportReasons = map[string]bool{
+	"spam":            true,
+	"scam":            true,
+	"harassment":      true,
+	"inappropriate":   true,
+	"prohibited_item": true,
+	"counterfeit":     true,
+	"other":           true,
+}
+
+// reportTargetTables maps target types to the table whose hidden_at moderation sets
+var reportTargetTables = map[string]string{
+	ReportTargetProduct: "products",
+	ReportTargetUser:    "users",
+	ReportTargetMessage: "messages",
+}
+
+const maxReportDetailsLength = 1000
+
+// CreateReportRequest reports a product, user or message
+type CreateReportRequest struct {
+	TargetType string    `json:"target_type" binding:"required"`
+	TargetID   uuid.UUID `json:"target_id" binding:"required"`
+	Reason     string    `json:"reason" binding:"required"`
+	Details    string    `json:"details"`
+}
+
+// ModerationActionRequest carries the moderator's note when resolving or dismissing reports
+type ModerationActionRequest struct {
+	Note string `json:"note"`
+}
+
+// ModerationItemDTO groups the reports about one target in the moderation queue
+type ModerationItemDTO struct {
+	TargetType       string          `json:"targetType"`
+	TargetID         string          `json:"targetId"`
+	Target           interface{}     `json:"target"` // ProductDTO, AdminUserDTO or MessageDTO; nil once purged
+	Hidden           bool            `json:"hidden"`
+	ReportCount      int             `json:"reportCount"`
+	Reasons          map[string]int  `json:"reasons"`
+	FirstReportedAt  string          `json:"firstReportedAt"`
+	LatestReportedAt string          `json:"latestReportedAt"`
+	Reports          []models.Report `json:"reports"`
+}
+
+// CreateReport flags a product, user or message for the moderators. Once REPORT_AUTO_HIDE_THRESHOLD
+// distinct users have open reports about the same target it is hidden until a moderator decides.
+func CreateReport(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	reporterID := userID.(uuid.UUID)
+
+	var req CreateReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if _, ok := reportTargetTables[req.TargetType]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "target_type must be product, user or message"})
+		return
+	}
+	if !reportReasons[req.Reason] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reason must be one of spam, scam, harassment, inappropriate, prohibited_item, counterfeit or other"})
+		return
+	}
+	details := strings.TrimSpace(req.Details)
+	if req.Reason == "other" && details == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "details are required when the reason is other"})
+		return
+	}
+	if utf8.RuneCountInString(details) > maxReportDetailsLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("details can be at most %d characters", maxReportDetailsLength)})
+		return
+	}
+
+	if status, err := checkReportTarget(reporterID, req.TargetType, req.TargetID); err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	var existing int64
+	config.DB.Model(&models.Report{}).
+		Where("reporter_id = ? AND target_type = ? AND target_id = ?", reporterID, req.TargetType, req.TargetID).
+		Count(&existing)
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "You already reported this"})
+		return
+	}
+
+	report := models.Report{
+		ReporterID: reporterID,
+		TargetType: req.TargetType,
+		TargetID:   req.TargetID,
+		Reason:     req.Reason,
+		Details:    details,
+		Status:     ReportStatusOpen,
+	}
+	if err := config.DB.Create(&report).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create report"})
+		return
+	}
+
+	// Reports are unique per reporter, so open reports count distinct users
+	var open int64
+	config.DB.Model(&models.Report{}).
+		Where("target_type = ? AND target_id = ? AND status = ?", report.TargetType, report.TargetID, ReportStatusOpen).
+		Count(&open)
+	if open >= int64(config.ReportAutoHideThreshold()) {
+		result := config.DB.Table(reportTargetTables[report.TargetType]).
+			Where("id = ? AND hidden_at IS NULL", report.TargetID).
+			Update("hidden_at", time.Now())
+		if result.Error != nil {
+			log.Printf("Failed to hide reported %s %s: %v", report.TargetType, report.TargetID, result.Error)
+		} else if result.RowsAffected > 0 {
+			log.Printf("Hid %s %s after %d reports", report.TargetType, report.TargetID, open)
+		}
+	}
+
+	c.JSON(http.StatusCreated, report)
+}
+
+// checkReportTarget makes sure the target exists and is something the reporter can report:
+// not their own, and for messages only those sent to them
+func checkReportTarget(reporterID uuid.UUID, targetType string, targetID uuid.UUID) (int, error) {
+	switch targetType {
+	case ReportTargetProduct:
+		var product models.Product
+		if err := config.DB.First(&product, targetID).Error; err != nil {
+			return http.StatusNotFound, fmt.Errorf("Product not found")
+		}
+		if product.SellerID == reporterID {
+			return http.StatusBadRequest, fmt.Errorf("You cannot report your own listing")
+		}
+	case ReportTargetUser:
+		var user models.User
+		if err := config.DB.First(&user, targetID).Error; err != nil {
+			return http.StatusNotFound, fmt.Errorf("User not found")
+		}
+		if user.ID == reporterID {
+			return http.StatusBadRequest, fmt.Errorf("You cannot report yourself")
+		}
+	case ReportTargetMessage:
+		var message models.Message
+		if err := config.DB.First(&message, targetID).Error; err != nil {
+			return http.StatusNotFound, fmt.Errorf("Message not found")
+		}
+		if message.IsSystem || message.FromID == reporterID {
+			return http.StatusBadRequest, fmt.Errorf("You can only report messages sent to you")
+		}
+		var participant int64
+		config.DB.Table("chat_participants").Where("chat_id = ? AND user_id = ?", message.ChatID, reporterID).Count(&participant)
+		if participant == 0 {
+			return http.StatusNotFound, fmt.Errorf("Message not found")
+		}
+	}
+	return 0, nil
+}
+
+// GetModerationQueue lists reported targets with their reports, most reported first
+// (?status=open|resolved|dismissed, default open, and optionally ?target_type=)
+func GetModerationQueue(c *gin.Context) {
+	status := c.DefaultQuery("status", ReportStatusOpen)
+	query := config.DB.Where("status = ?", status)
+	if targetType := c.Query("target_type"); targetType != "" {
+		if _, ok := reportTargetTables[targetType]; !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "target_type must be product, user or message"})
+			return
+		}
+		query = query.Where("target_type = ?", targetType)
+	}
+
+	var reports []models.Report
+	if err := query.Order("created_at ASC").Find(&reports).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reports"})
+		return
+	}
+
+	items := []*ModerationItemDTO{}
+	byTarget := map[string]*ModerationItemDTO{}
+	for _, report := range reports {
+		key := report.TargetType + "/" + report.TargetID.String()
+		item, ok := byTarget[key]
+		if !ok {
+			item = &ModerationItemDTO{
+				TargetType:      report.TargetType,
+				TargetID:        report.TargetID.String(),
+				Reasons:         map[string]int{},
+				FirstReportedAt: report.CreatedAt.Format("2006-01-02T15:04:05.000Z"),
+			}
+			byTarget[key] = item
+			items = append(items, item)
+		}
+		item.ReportCount++
+		item.Reasons[report.Reason]++
+		item.LatestReportedAt = report.CreatedAt.Format("2006-01-02T15:04:05.000Z")
+		item.Reports = append(item.Reports, report)
+	}
+	for _, item := range items {
+		item.Target, item.Hidden = loadModerationTarget(item.TargetType, item.TargetID)
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].ReportCount > items[j].ReportCount })
+
+	c.JSON(http.StatusOK, items)
+}
+
+// loadModerationTarget returns what admins see of a reported target, including deleted and hidden ones
+func loadModerationTarget(targetType, targetID string) (interface{}, bool) {
+	switch targetType {
+	case ReportTargetProduct:
+		var product models.Product
+		if err := config.DB.Unscoped().Preload("Seller", withDeleted).First(&product, "id = ?", targetID).Error; err != nil {
+			return nil, false
+		}
+		return ProductDTOFromModel(&product), product.HiddenAt != nil
+	case ReportTargetUser:
+		var user models.User
+		if err := config.DB.Unscoped().Preload("College").First(&user, "id = ?", targetID).Error; err != nil {
+			return nil, false
+		}
+		return AdminUserDTOFromModel(&user), user.HiddenAt != nil
+	case ReportTargetMessage:
+		var message models.Message
+		if err := config.DB.Preload("From", withDeleted).First(&message, "id = ?", targetID).Error; err != nil {
+			return nil, false
+		}
+		dto := MessageDTOFromModel(&message)
+		dto.Text = message.Text // moderators need to read hidden messages
+		return dto, message.HiddenAt != nil
+	}
+	return nil, false
+}
+
+// ResolveReport upholds the reports about a target: a product is removed, a user or message stays hidden
+func ResolveReport(c *gin.Context) {
+	moderateReport(c, ReportStatusResolved)
+}
+
+// DismissReport rejects the reports about a target and makes it visible again
+func DismissReport(c *gin.Context) {
+	moderateReport(c, ReportStatusDismissed)
+}
+
+// moderateReport closes every open report about the report's target with one decision
+func moderateReport(c *gin.Context, status string) {
+	adminID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	reportID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid report ID"})
+		return
+	}
+
+	var req ModerationActionRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	var report models.Report
+	if err := config.DB.First(&report, reportID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Report not found"})
+		return
+	}
+	if report.Status != ReportStatusOpen {
+		c.JSON(http.StatusConflict, gin.H{"error": "Report was already " + report.Status})
+		return
+	}
+
+	var declined []models.PurchaseRequest
+	var closed int64
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		table := reportTargetTables[report.TargetType]
+		if status == ReportStatusDismissed {
+			// Dismissing a later batch of reports must not undo an earlier decision to uphold them
+			var upheld int64
+			err := tx.Model(&models.Report{}).
+				Where("target_type = ? AND target_id = ? AND status = ?", report.TargetType, report.TargetID, ReportStatusResolved).
+				Count(&upheld).Error
+			if err != nil {
+				return err
+			}
+			if upheld == 0 {
+				if err := tx.Table(table).Where("id = ?", report.TargetID).Update("hidden_at", nil).Error; err != nil {
+					return err
+				}
+			}
+		} else if report.TargetType == ReportTargetProduct {
+			var product models.Product
+			if tx.First(&product, report.TargetID).Error == nil {
+				var err error
+				if declined, err = removeListing(tx, &product, adminID.(uuid.UUID)); err != nil {
+					return err
+				}
+			}
+		} else {
+			err := tx.Table(table).Where("id = ? AND hidden_at IS NULL", report.TargetID).Update("hidden_at", time.Now()).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		result := tx.Model(&models.Report{}).
+			Where("target_type = ? AND target_id = ? AND status = ?", report.TargetType, report.TargetID, ReportStatusOpen).
+			Updates(map[string]interface{}{
+				"status":         status,
+				"resolved_by_id": adminID,
+				"resolved_at":    time.Now(),
+				"resolution":     strings.TrimSpace(req.Note),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		closed = result.RowsAffected
+
+		action := AuditReportResolved
+		if status == ReportStatusDismissed {
+			action = AuditReportDismissed
+		}
+		return recordAudit(tx, c, AuditEntry{
+			Action:     action,
+			TargetType: report.TargetType,
+			TargetID:   report.TargetID,
+			Before:     gin.H{"reports": ReportStatusOpen},
+			After:      gin.H{"reports": status, "closed": closed},
+			Note:       strings.TrimSpace(req.Note),
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update reports"})
+		return
+	}
+	log.Printf("Reports about %s %s %s by %v", report.TargetType, report.TargetID, status, adminID)
+
+	for _, request := range declined {
+		notify(NotificationEvent{
+			UserID: request.BuyerID,
+			Type:   NotificationEventPurchaseRequestDeclined,
+			Title:  "Purchase request declined",
+			Body:   "This listing was removed by a moderator",
+			Link:   "/chats",
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("%d report(s) %s", closed, status)})
+}
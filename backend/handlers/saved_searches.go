@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"marketplace-backend/config"
+	"marketplace-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const maxSavedSearchesPerUser = 20
+
+// SavedSearchRequest creates or replaces a saved search
+type SavedSearchRequest struct {
+	Name string `json:"name" binding:"required"`
+	ProductFilter
+	Alerts *bool `json:"alerts"`
+}
+
+// savedSearchFilter returns the listing filter a saved search represents
+func savedSearchFilter(search *models.SavedSearch) ProductFilter {
+	filter := ProductFilter{
+		Keywords:   search.Keywords,
+		Category:   search.Category,
+		MaxPrice:   search.MaxPrice,
+		Condition:  search.Condition,
+		ISBN:       search.ISBN,
+		CourseCode: search.CourseCode,
+	}
+	if search.Attributes != "" {
+		json.Unmarshal([]byte(search.Attributes), &filter.Attributes)
+	}
+	return filter
+}
+
+// applyTo validates the request and copies it onto the saved search
+func (req *SavedSearchRequest) applyTo(search *models.SavedSearch) error {
+	filter := ProductFilter{
+		Keywords:   strings.TrimSpace(req.Keywords),
+		Category:   strings.TrimSpace(req.Category),
+		MaxPrice:   req.MaxPrice,
+		Condition:  strings.TrimSpace(req.Condition),
+		CourseCode: normalizeCourseCode(req.CourseCode),
+	}
+	if strings.TrimSpace(req.ISBN) != "" {
+		isbn, err := normalizeISBN(req.ISBN)
+		if err != nil {
+			return err
+		}
+		filter.ISBN = isbn
+	}
+	for key, value := range req.Attributes {
+		name, bound := splitAttributeFilter(key)
+		if name == "" || strings.TrimSpace(value) == "" {
+			continue
+		}
+		if bound != "" {
+			if _, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err != nil {
+				return fmt.Errorf("attribute bound %s must be a number", key)
+			}
+			name += "." + bound
+		}
+		if filter.Attributes == nil {
+			filter.Attributes = map[string]string{}
+		}
+		filter.Attributes[name] = strings.TrimSpace(value)
+	}
+	if filter.IsEmpty() {
+		return fmt.Errorf("a saved search needs at least one of keywords, category, max_price, condition, isbn, course_code or attributes")
+	}
+	if filter.MaxPrice != nil && *filter.MaxPrice < 0 {
+		return fmt.Errorf("max_price cannot be negative")
+	}
+
+	search.Name = strings.TrimSpace(req.Name)
+	search.Keywords = filter.Keywords
+	search.Category = filter.Category
+	search.MaxPrice = filter.MaxPrice
+	search.Condition = filter.Condition
+	search.ISBN = filter.ISBN
+	search.CourseCode = filter.CourseCode
+	search.Attributes = ""
+	if filter.Attributes != nil {
+		attributesJSON, _ := json.Marshal(filter.Attributes)
+		search.Attributes = string(attributesJSON)
+	}
+	if req.Alerts != nil {
+		search.Alerts = *req.Alerts
+	}
+	return nil
+}
+
+// GetSavedSearches returns the caller's saved searches
+func GetSavedSearches(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var searches []models.SavedSearch
+	if err := config.DB.Where("user_id = ?", userID).Order("created_at DESC").Find(&searches).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch saved searches"})
+		return
+	}
+
+	c.JSON(http.StatusOK, searches)
+}
+
+// GetSavedSearch returns one saved search
+func GetSavedSearch(c *gin.Context) {
+	search, ok := loadOwnSavedSearch(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, search)
+}
+
+// CreateSavedSearch saves a new listing query for the caller
+func CreateSavedSearch(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req SavedSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var count int64
+	config.DB.Model(&models.SavedSearch{}).Where("user_id = ?", userID).Count(&count)
+	if count >= maxSavedSearchesPerUser {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("You can save at most %d searches", maxSavedSearchesPerUser)})
+		return
+	}
+
+	search := models.SavedSearch{UserID: userID.(uuid.UUID), Alerts: true}
+	if err := req.applyTo(&search); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Select all columns so alerts=false is stored instead of the column default
+	if err := config.DB.Select("*").Create(&search).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create saved search"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, search)
+}
+
+// UpdateSavedSearch replaces a saved search
+func UpdateSavedSearch(c *gin.Context) {
+	search, ok := loadOwnSavedSearch(c)
+	if !ok {
+		return
+	}
+
+	var req SavedSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := req.applyTo(search); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := config.DB.Save(search).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update saved search"})
+		return
+	}
+
+	c.JSON(http.StatusOK, search)
+}
+
+// DeleteSavedSearch removes a saved search
+func DeleteSavedSearch(c *gin.Context) {
+	search, ok := loadOwnSavedSearch(c)
+	if !ok {
+		return
+	}
+
+	if err := config.DB.Delete(search).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete saved search"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Saved search deleted successfully"})
+}
+
+func loadOwnSavedSearch(c *gin.Context) (*models.SavedSearch, bool) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return nil, false
+	}
+
+	searchID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid saved search ID"})
+		return nil, false
+	}
+
+	var search models.SavedSearch
+	if err := config.DB.Where("id = ? AND user_id = ?", searchID, userID).First(&search).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Saved search not found"})
+		return nil, false
+	}
+
+	return &search, true
+}
+
+// matchSavedSearches notifies users in the product's college whose saved searches match a new listing
+func matchSavedSearches(product models.Product) {
+	var searches []models.SavedSearch
+	err := config.DB.
+		Joins("JOIN users ON users.id = saved_searches.user_id").
+		Where("saved_searches.alerts = ? AND users.college_id = ? AND saved_searches.user_id <> ?", true, product.CollegeID, product.SellerID).
+		Where("(saved_searches.category = '' OR LOWER(saved_searches.category) IN ?)", categoryMatchKeys(&product)).
+		Where("(saved_searches.max_price IS NULL OR saved_searches.max_price >= ?)", product.Price).
+		Find(&searches).Error
+	if err != nil {
+		log.Printf("Failed to load saved searches for product %s: %v", product.ID, err)
+		return
+	}
+
+	notified := map[uuid.UUID]bool{}
+	for _, search := range searches {
+		if notified[search.UserID] || !savedSearchFilter(&search).Matches(&product) || usersBlocked(search.UserID, product.SellerID) {
+			continue
+		}
+		notified[search.UserID] = true
+
+		notify(NotificationEvent{
+			UserID: search.UserID,
+			Type:   NotificationEventSavedSearchMatch,
+			Title:  fmt.Sprintf("New match for \"%s\"", search.Name),
+			Body:   fmt.Sprintf("%s – $%.2f", product.Title, product.Price),
+			Link:   "/product/" + product.ID.String(),
+		})
+		config.DB.Model(&search).Update("last_notified_at", time.Now())
+	}
+}
@@ -0,0 +1,336 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"marketplace-backend/config"
+	"marketplace-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultStorefrontPageSize = 12
+	maxStorefrontPageSize     = 50
+	// storefrontResponseWindowDays limits response-time statistics to recent chats
+	storefrontResponseWindowDays = 90
+	// Unanswered messages younger than this don't count against the response rate yet
+	storefrontResponseGrace = 24 * time.Hour
+	maxReviewCommentLength  = 1000
+)
+
+// StorefrontDTO is everything a buyer sees on a seller's public page
+type StorefrontDTO struct {
+	Seller       PublicUserDTO         `json:"seller"`
+	MemberSince  string                `json:"memberSince"`
+	SoldCount    int64                 `json:"soldCount"`
+	Followers    int64                 `json:"followers"`
+	Rating       SellerRatingSummary   `json:"rating"`
+	ResponseTime SellerResponseStats   `json:"responseTime"`
+	Active       StorefrontListingPage `json:"active"`
+	Sold         StorefrontListingPage `json:"sold"`
+}
+
+// SellerRatingSummary aggregates the reviews buyers left the seller
+type SellerRatingSummary struct {
+	Average *float64 `json:"average"` // rounded to one decimal, nil without reviews
+	Count   int64    `json:"count"`
+	// Distribution counts reviews per star, index 0 being 1 star
+	Distribution [5]int64 `json:"distribution"`
+}
+
+// SellerResponseStats describes how quickly the seller answers buyers in chat
+type SellerResponseStats struct {
+	MedianMinutes *int `json:"medianMinutes"` // nil until the seller has answered a message
+	// ResponseRate is the percentage of buyer messages the seller answered, nil without any
+	ResponseRate *int  `json:"responseRate"`
+	Chats        int64 `json:"chats"` // chats the statistics are based on
+	WindowDays   int   `json:"windowDays"`
+}
+
+// StorefrontListingPage is one page of a seller's listings
+type StorefrontListingPage struct {
+	Items  []ProductDTO `json:"items"`
+	Total  int64        `json:"total"`
+	Limit  int          `json:"limit"`
+	Offset int          `json:"offset"`
+}
+
+// CreateSellerReviewRequest rates the seller of an accepted purchase request
+type CreateSellerReviewRequest struct {
+	Rating  int    `json:"rating" binding:"required"`
+	Comment string `json:"comment"`
+}
+
+// GetStorefront returns a seller's public profile, rating summary, response-time statistics and
+// paginated active and sold listings (?limit=&active_offset=&sold_offset=)
+func GetStorefront(c *gin.Context) {
+	sellerID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var seller models.User
+	if err := config.DB.Preload("College").First(&seller, sellerID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	// Hidden, suspended and banned sellers and blocked users look like they don't exist
+	if viewerID, exists := c.Get("userID"); !exists || viewerID != seller.ID {
+		if (sellerHidden(&seller) && !isAdminRequest(c)) || (exists && usersBlocked(viewerID.(uuid.UUID), seller.ID)) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultStorefrontPageSize)))
+	if err != nil || limit < 1 || limit > maxStorefrontPageSize {
+		limit = defaultStorefrontPageSize
+	}
+
+	storefront := StorefrontDTO{
+		Seller:      PublicUserDTOFromModel(&seller),
+		MemberSince: seller.CreatedAt.Format("2006-01-02T15:04:05.000Z"),
+	}
+
+	active, err := storefrontListings(&seller, []string{ProductStatusAvailable, ProductStatusRequested},
+		"COALESCE(published_at, created_at) DESC", limit, storefrontOffset(c, "active_offset"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch listings"})
+		return
+	}
+	sold, err := storefrontListings(&seller, []string{ProductStatusSold},
+		"COALESCE(sold_at, updated_at) DESC", limit, storefrontOffset(c, "sold_offset"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch listings"})
+		return
+	}
+	storefront.Active, storefront.Sold = active, sold
+
+	// Archived listings were sold too, they just no longer show up in the sold list
+	err = config.DB.Model(&models.Product{}).
+		Where("seller_id = ? AND status IN ?", seller.ID, []string{ProductStatusSold, ProductStatusArchived}).
+		Count(&storefront.SoldCount).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch listings"})
+		return
+	}
+
+	if err := config.DB.Model(&models.Follow{}).Where("seller_id = ?", seller.ID).Count(&storefront.Followers).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch followers"})
+		return
+	}
+	if storefront.Rating, err = sellerRatingSummary(seller.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch ratings"})
+		return
+	}
+	if storefront.ResponseTime, err = sellerResponseStats(seller.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute response times"})
+		return
+	}
+
+	c.JSON(http.StatusOK, storefront)
+}
+
+func storefrontOffset(c *gin.Context, name string) int {
+	offset, err := strconv.Atoi(c.DefaultQuery(name, "0"))
+	if err != nil || offset < 0 {
+		return 0
+	}
+	return offset
+}
+
+func storefrontListings(seller *models.User, statuses []string, order string, limit, offset int) (StorefrontListingPage, error) {
+	page := StorefrontListingPage{Items: []ProductDTO{}, Limit: limit, Offset: offset}
+
+	query := config.DB.Model(&models.Product{}).Where("seller_id = ? AND status IN ? AND hidden_at IS NULL", seller.ID, statuses)
+	if err := query.Count(&page.Total).Error; err != nil {
+		return page, err
+	}
+
+	var products []models.Product
+	if err := query.Order(order).Limit(limit).Offset(offset).Find(&products).Error; err != nil {
+		return page, err
+	}
+	for i := range products {
+		products[i].Seller = *seller
+		page.Items = append(page.Items, *ProductDTOFromModel(&products[i]))
+	}
+	return page, nil
+}
+
+func sellerRatingSummary(sellerID uuid.UUID) (SellerRatingSummary, error) {
+	var summary SellerRatingSummary
+	var counts []struct {
+		Rating int
+		Count  int64
+	}
+	err := config.DB.Model(&models.SellerReview{}).
+		Select("rating, COUNT(*) AS count").
+		Where("seller_id = ?", sellerID).
+		Group("rating").
+		Scan(&counts).Error
+	if err != nil {
+		return summary, err
+	}
+
+	var total int64
+	for _, row := range counts {
+		if row.Rating < 1 || row.Rating > 5 {
+			continue
+		}
+		summary.Distribution[row.Rating-1] = row.Count
+		summary.Count += row.Count
+		total += int64(row.Rating) * row.Count
+	}
+	if summary.Count > 0 {
+		average := math.Round(float64(total)/float64(summary.Count)*10) / 10
+		summary.Average = &average
+	}
+	return summary, nil
+}
+
+// sellerResponseStats measures, per chat about one of the seller's listings, the time from a buyer's
+// first unanswered message to the seller's next reply
+func sellerResponseStats(sellerID uuid.UUID) (SellerResponseStats, error) {
+	stats := SellerResponseStats{WindowDays: storefrontResponseWindowDays}
+
+	var messages []struct {
+		ChatID    uuid.UUID
+		FromID    uuid.UUID
+		CreatedAt time.Time
+	}
+	// Deleted listings are included: the seller still answered (or ignored) those buyers
+	err := config.DB.Table("messages").
+		Select("messages.chat_id, messages.from_id, messages.created_at").
+		Joins("JOIN chats ON chats.id = messages.chat_id").
+		Joins("JOIN products ON products.id = chats.product_id").
+		Where("products.seller_id = ? AND messages.is_system = ?", sellerID, false).
+		Where("messages.created_at > ?", time.Now().AddDate(0, 0, -storefrontResponseWindowDays)).
+		Order("messages.chat_id, messages.created_at ASC").
+		Scan(&messages).Error
+	if err != nil {
+		return stats, err
+	}
+
+	var delays []time.Duration
+	var unanswered int
+	var chatID uuid.UUID
+	var waitingSince *time.Time
+	closeChat := func() {
+		if waitingSince != nil && time.Since(*waitingSince) > storefrontResponseGrace {
+			unanswered++
+		}
+		waitingSince = nil
+	}
+	for i := range messages {
+		m := &messages[i]
+		if m.ChatID != chatID {
+			closeChat()
+			chatID = m.ChatID
+			stats.Chats++
+		}
+		switch {
+		case m.FromID != sellerID && waitingSince == nil:
+			waitingSince = &m.CreatedAt
+		case m.FromID == sellerID && waitingSince != nil:
+			delays = append(delays, m.CreatedAt.Sub(*waitingSince))
+			waitingSince = nil
+		}
+	}
+	closeChat()
+
+	if len(delays) > 0 {
+		sort.Slice(delays, func(i, j int) bool { return delays[i] < delays[j] })
+		median := delays[len(delays)/2]
+		if len(delays)%2 == 0 {
+			median = (delays[len(delays)/2-1] + median) / 2
+		}
+		minutes := int(math.Round(median.Minutes()))
+		stats.MedianMinutes = &minutes
+	}
+	if asked := len(delays) + unanswered; asked > 0 {
+		rate := int(math.Round(float64(len(delays)) * 100 / float64(asked)))
+		stats.ResponseRate = &rate
+	}
+	return stats, nil
+}
+
+// CreateSellerReview lets the buyer of an accepted purchase request rate the seller once
+func CreateSellerReview(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	requestID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request ID"})
+		return
+	}
+
+	var req CreateSellerReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Rating < 1 || req.Rating > 5 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "rating must be between 1 and 5"})
+		return
+	}
+	comment := strings.TrimSpace(req.Comment)
+	if utf8.RuneCountInString(comment) > maxReviewCommentLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("comment can be at most %d characters", maxReviewCommentLength)})
+		return
+	}
+
+	var request models.PurchaseRequest
+	if err := config.DB.First(&request, requestID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Purchase request not found"})
+		return
+	}
+	if request.BuyerID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the buyer can review this purchase"})
+		return
+	}
+	if request.Status != "accepted" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only accepted purchases can be reviewed"})
+		return
+	}
+	// Requests from before buyers were taken from the token may name the seller as the buyer
+	if request.BuyerID == request.SellerID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot review yourself"})
+		return
+	}
+
+	var existing int64
+	config.DB.Model(&models.SellerReview{}).Where("purchase_request_id = ?", request.ID).Count(&existing)
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "You already reviewed this purchase"})
+		return
+	}
+
+	review := models.SellerReview{
+		PurchaseRequestID: request.ID,
+		SellerID:          request.SellerID,
+		BuyerID:           request.BuyerID,
+		Rating:            req.Rating,
+		Comment:           comment,
+	}
+	if err := config.DB.Create(&review).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save review"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, review)
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"marketplace-backend/config"
+	"marketplace-backend/middleware"
+	"marketplace-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const maxSuspensionDays = 365
+
+type SuspendUserRequest struct {
+	Days   int    `json:"days" binding:"required,min=1"`
+	Reason string `json:"reason" binding:"required"`
+}
+
+type BanUserRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// SuspendUser keeps a user out for a number of days: they can't sign in or use their token, and their
+// listings and storefront are hidden until the suspension ends
+func SuspendUser(c *gin.Context) {
+	var req SuspendUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Days > maxSuspensionDays {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Suspensions can last at most %d days; ban the user instead", maxSuspensionDays)})
+		return
+	}
+
+	until := time.Now().AddDate(0, 0, req.Days)
+	changeAccountStatus(c, AuditUserSuspended, func(user *models.User) {
+		user.SuspendedUntil = &until
+		user.BannedAt = nil
+		user.StatusReason = strings.TrimSpace(req.Reason)
+	})
+}
+
+// BanUser locks a user out for good and hides their listings and storefront
+func BanUser(c *gin.Context) {
+	var req BanUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	changeAccountStatus(c, AuditUserBanned, func(user *models.User) {
+		user.SuspendedUntil = nil
+		user.BannedAt = &now
+		user.StatusReason = strings.TrimSpace(req.Reason)
+	})
+}
+
+// ReinstateUser lifts a suspension or ban
+func ReinstateUser(c *gin.Context) {
+	changeAccountStatus(c, AuditUserReinstated, func(user *models.User) {
+		user.SuspendedUntil = nil
+		user.BannedAt = nil
+		user.StatusReason = ""
+	})
+}
+
+// changeAccountStatus applies a status change to the :id user, audits it and drops the user's cached
+// status so the auth middleware enforces it from their next request
+func changeAccountStatus(c *gin.Context, action string, apply func(user *models.User)) {
+	adminID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+	if userID == adminID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot change the status of your own account"})
+		return
+	}
+
+	var user models.User
+	if err := config.DB.Preload("College").First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if user.IsAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Admins cannot be suspended or banned"})
+		return
+	}
+
+	before := middleware.AccountStatusOf(&user)
+	apply(&user)
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&user).Select("suspended_until", "banned_at", "status_reason").Updates(&user).Error
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, c, AuditEntry{
+			Action:     action,
+			TargetType: "user",
+			TargetID:   user.ID,
+			Before:     before,
+			After:      middleware.AccountStatusOf(&user),
+			Note:       user.StatusReason,
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update account status"})
+		return
+	}
+	middleware.InvalidateAccountStatus(user.ID)
+	log.Printf("User %s %s by %v", user.ID, action, adminID)
+
+	c.JSON(http.StatusOK, AdminUserDTOFromModel(&user))
+}
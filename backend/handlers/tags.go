@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"marketplace-backend/config"
+	"marketplace-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultTagSuggestions = 10
+	maxTagSuggestions     = 50
+)
+
+// TagSuggestion is an autocomplete entry with the number of listings using the tag
+type TagSuggestion struct {
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
+// GetTags suggests tags starting with ?prefix=, ranked by how many listings in the caller's college use them
+func GetTags(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultTagSuggestions)))
+	if err != nil || limit < 1 || limit > maxTagSuggestions {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", maxTagSuggestions)})
+		return
+	}
+
+	var user models.User
+	if err := config.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	prefix := ""
+	if normalized := config.NormalizeTags([]string{c.Query("prefix")}); len(normalized) > 0 {
+		prefix = normalized[0]
+	}
+
+	suggestions := []TagSuggestion{}
+	result := config.DB.Table("tags").
+		Select("tags.name, COUNT(products.id) AS count").
+		Joins("JOIN product_tags ON product_tags.tag_id = tags.id").
+		Joins("JOIN products ON products.id = product_tags.product_id AND products.deleted_at IS NULL").
+		Where("products.college_id = ? AND tags.name LIKE ? ESCAPE '\\'", user.CollegeID, escapeLike(prefix)+"%").
+		Group("tags.name").
+		Order("count DESC, tags.name ASC").
+		Limit(limit).
+		Scan(&suggestions)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tags"})
+		return
+	}
+
+	c.JSON(http.StatusOK, suggestions)
+}
+
+// tagsFromJSON reads a "tags" value sent either as a JSON array or as a CSV/JSON string
+func tagsFromJSON(raw json.RawMessage) ([]string, error) {
+	var list []string
+	if err := json.Unmarshal(raw, &list); err == nil {
+		return config.NormalizeTags(list), nil
+	}
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return config.ParseTags(text), nil
+	}
+	return nil, fmt.Errorf("tags must be an array of strings or a comma-separated string")
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
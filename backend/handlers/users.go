@@ -1,15 +1,20 @@
 package handlers
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"strings"
+	"unicode/utf8"
 	"marketplace-backend/config"
 	"marketplace-backend/models"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
-// GetUser returns a user by ID
+// GetUser returns a user by ID: the full account to its owner and admins, the public profile to everyone else
 func GetUser(c *gin.Context) {
 	id := c.Param("id")
 	userID, err := uuid.Parse(id)
@@ -25,7 +30,7 @@ func GetUser(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, user)
+	c.JSON(http.StatusOK, userDTOFor(c, &user))
 }
 
 // CreateUser creates a new user
@@ -44,13 +49,14 @@ func CreateUser(c *gin.Context) {
 		return
 	}
 	user.CollegeID = defaultCollege.ID
+	user.IsAdmin = false // admin rights are granted by other admins, never on sign-up
 
 	// Check if user with email already exists
 	var existingUser models.User
 	if err := config.DB.Where("email = ?", user.Email).First(&existingUser).Error; err == nil {
 		// User exists, return the existing user
 		config.DB.Preload("College").First(&existingUser, existingUser.ID)
-		c.JSON(http.StatusOK, existingUser)
+		c.JSON(http.StatusOK, PublicUserDTOFromModel(&existingUser))
 		return
 	}
 
@@ -63,39 +69,139 @@ func CreateUser(c *gin.Context) {
 	// Preload relationships for response
 	config.DB.Preload("College").First(&user, user.ID)
 
-	c.JSON(http.StatusCreated, user)
+	c.JSON(http.StatusCreated, SelfUserDTOFromModel(&user))
 }
 
-// UpdateUser updates an existing user
-func UpdateUser(c *gin.Context) {
-	id := c.Param("id")
-	userID, err := uuid.Parse(id)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
-		return
+// UpdateProfileRequest holds the profile fields a user can change; omitted fields are left as they are.
+// Email, admin role and college are not editable here.
+type UpdateProfileRequest struct {
+	Name       *string `json:"name"`
+	Year       *string `json:"year"`
+	Department *string `json:"department"`
+}
+
+const (
+	maxUserNameLength       = 100
+	maxUserYearLength       = 20
+	maxUserDepartmentLength = 100
+)
+
+// updates validates the request and returns the columns to change, or the problem with each invalid field
+func (r *UpdateProfileRequest) updates() (map[string]interface{}, map[string]string) {
+	updates := map[string]interface{}{}
+	fieldErrors := map[string]string{}
+
+	if r.Name != nil {
+		name := strings.TrimSpace(*r.Name)
+		switch {
+		case name == "":
+			fieldErrors["name"] = "Name cannot be empty"
+		case utf8.RuneCountInString(name) > maxUserNameLength:
+			fieldErrors["name"] = fmt.Sprintf("Name can be at most %d characters", maxUserNameLength)
+		default:
+			updates["name"] = name
+		}
+	}
+	if r.Year != nil {
+		year := strings.TrimSpace(*r.Year)
+		if utf8.RuneCountInString(year) > maxUserYearLength {
+			fieldErrors["year"] = fmt.Sprintf("Year can be at most %d characters", maxUserYearLength)
+		} else {
+			updates["year"] = year
+		}
 	}
+	if r.Department != nil {
+		department := strings.TrimSpace(*r.Department)
+		if utf8.RuneCountInString(department) > maxUserDepartmentLength {
+			fieldErrors["department"] = fmt.Sprintf("Department can be at most %d characters", maxUserDepartmentLength)
+		} else {
+			updates["department"] = department
+		}
+	}
+	return updates, fieldErrors
+}
 
-	var user models.User
-	result := config.DB.First(&user, userID)
-	if result.Error != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+// UpdateUser updates a user's profile; users can only edit their own, admins anyone's
+func UpdateUser(c *gin.Context) {
+	user, ok := loadEditableUser(c)
+	if !ok {
 		return
 	}
 
-	var updateData models.User
-	if err := c.ShouldBindJSON(&updateData); err != nil {
+	// Reject fields outside the profile (email, is_admin, college_id, ...) instead of silently ignoring them
+	var req UpdateProfileRequest
+	decoder := json.NewDecoder(c.Request.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	result = config.DB.Model(&user).Updates(updateData)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+	updates, fieldErrors := req.updates()
+	if len(fieldErrors) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Invalid profile", "fields": fieldErrors})
 		return
 	}
 
+	if len(updates) > 0 {
+		before := gin.H{"name": user.Name, "year": user.Year, "department": user.Department}
+		after := gin.H{}
+		for column, value := range before {
+			after[column] = value
+		}
+		for column, value := range updates {
+			after[column] = value
+		}
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(user).Updates(updates).Error; err != nil {
+				return err
+			}
+			// Users editing their own profile are not audited, admins editing someone else's are
+			if callerID, _ := c.Get("userID"); callerID == user.ID {
+				return nil
+			}
+			return recordAudit(tx, c, AuditEntry{
+				Action:     AuditUserUpdated,
+				TargetType: "user",
+				TargetID:   user.ID,
+				Before:     before,
+				After:      after,
+			})
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+			return
+		}
+	}
+
 	// Preload relationships for response
-	config.DB.Preload("College").First(&user, user.ID)
+	config.DB.Preload("College").First(user, user.ID)
+
+	c.JSON(http.StatusOK, userDTOFor(c, user))
+}
+
+// loadEditableUser loads the :id user when the caller may edit it, otherwise responds with the error
+func loadEditableUser(c *gin.Context) (*models.User, bool) {
+	callerID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return nil, false
+	}
 
-	c.JSON(http.StatusOK, user)
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return nil, false
+	}
+	if callerID != userID && !isAdminRequest(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only update your own profile"})
+		return nil, false
+	}
+
+	var user models.User
+	if err := config.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return nil, false
+	}
+	return &user, true
 }
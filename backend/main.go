@@ -3,6 +3,7 @@ package main
 import (
 	"log"
 	"marketplace-backend/config"
+	"marketplace-backend/handlers"
 	"marketplace-backend/routes"
 
 	"github.com/gin-contrib/cors"
@@ -16,6 +17,18 @@ func main() {
 	// Connect to Azure Blob Storage
 	config.ConnectAzureBlobStorage()
 
+	// Configure outbound email (SMTP or file drop)
+	config.ConnectEmailSender()
+
+	// Configure Web Push (VAPID)
+	config.ConnectWebPush()
+
+	// Configure book metadata lookups (bundled catalog, optionally Open Library)
+	config.ConnectBookMetadata()
+
+	// Start queue workers and scheduled jobs
+	handlers.StartBackgroundJobs()
+
 	// Create Gin router
 	r := gin.Default()
 
@@ -27,9 +40,9 @@ func main() {
 			"http://localhost:3000",
 			"https://ashy-coast-049069600.2.azurestaticapps.net", // Your actual frontend URL
 		},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "If-Match"},
+		ExposeHeaders:    []string{"Content-Length", "ETag"},
 		AllowCredentials: true,
 	}))
 
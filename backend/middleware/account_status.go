@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"marketplace-backend/config"
+	"marketplace-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Account statuses
+const (
+	AccountStatusActive    = "active"
+	AccountStatusSuspended = "suspended"
+	AccountStatusBanned    = "banned"
+	AccountStatusDeleted   = "deleted"
+)
+
+// Error codes returned with 401/403 responses so the frontend can tell why a signed-in user was turned away
+const (
+	ErrorCodeAccountSuspended = "account_suspended"
+	ErrorCodeAccountBanned    = "account_banned"
+	ErrorCodeAccountDeleted   = "account_deleted"
+)
+
+// Old entries are swept out once the cache grows past this many users
+const accountStatusCacheSweepSize = 10000
+
+// AccountStatus is what the auth middleware knows about the account behind a token
+type AccountStatus struct {
+	Status         string     `json:"status"`
+	SuspendedUntil *time.Time `json:"suspended_until,omitempty"`
+	Reason         string     `json:"reason,omitempty"`
+}
+
+type cachedAccountStatus struct {
+	status  AccountStatus
+	expires time.Time
+}
+
+var accountStatusCache = struct {
+	sync.Mutex
+	entries map[uuid.UUID]cachedAccountStatus
+}{entries: map[uuid.UUID]cachedAccountStatus{}}
+
+// AccountStatusOf works out the status of a loaded user; a suspension that has run out counts as active
+func AccountStatusOf(user *models.User) AccountStatus {
+	switch {
+	case user.DeletedAt.Valid:
+		return AccountStatus{Status: AccountStatusDeleted}
+	case user.BannedAt != nil:
+		return AccountStatus{Status: AccountStatusBanned, Reason: user.StatusReason}
+	case user.SuspendedUntil != nil && user.SuspendedUntil.After(time.Now()):
+		return AccountStatus{Status: AccountStatusSuspended, SuspendedUntil: user.SuspendedUntil, Reason: user.StatusReason}
+	}
+	return AccountStatus{Status: AccountStatusActive}
+}
+
+// InvalidateAccountStatus drops the cached status of a user so a ban, suspension or reinstatement
+// applies from their next request
+func InvalidateAccountStatus(userID uuid.UUID) {
+	accountStatusCache.Lock()
+	delete(accountStatusCache.entries, userID)
+	accountStatusCache.Unlock()
+}
+
+// lookupAccountStatus returns the user's status, from the cache while it is fresh
+func lookupAccountStatus(userID uuid.UUID) (AccountStatus, error) {
+	now := time.Now()
+	accountStatusCache.Lock()
+	cached, ok := accountStatusCache.entries[userID]
+	accountStatusCache.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.status, nil
+	}
+
+	var user models.User
+	err := config.DB.Unscoped().Select("id", "deleted_at", "suspended_until", "banned_at", "status_reason").First(&user, userID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		user.DeletedAt = gorm.DeletedAt{Time: now, Valid: true}
+	} else if err != nil {
+		return AccountStatus{}, err
+	}
+	status := AccountStatusOf(&user)
+
+	// A suspension ending before the entry would expire is picked up when it ends
+	expires := now.Add(time.Duration(config.AccountStatusCacheSeconds()) * time.Second)
+	if status.SuspendedUntil != nil && status.SuspendedUntil.Before(expires) {
+		expires = *status.SuspendedUntil
+	}
+
+	accountStatusCache.Lock()
+	if len(accountStatusCache.entries) >= accountStatusCacheSweepSize {
+		for id, entry := range accountStatusCache.entries {
+			if !now.Before(entry.expires) {
+				delete(accountStatusCache.entries, id)
+			}
+		}
+	}
+	accountStatusCache.entries[userID] = cachedAccountStatus{status: status, expires: expires}
+	accountStatusCache.Unlock()
+	return status, nil
+}
+
+// AbortWithAccountStatus turns away a user whose account is not active: suspended and banned accounts
+// get a 403, tokens of deleted accounts a 401
+func AbortWithAccountStatus(c *gin.Context, status AccountStatus) {
+	switch status.Status {
+	case AccountStatusSuspended:
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error":           "Your account is suspended",
+			"code":            ErrorCodeAccountSuspended,
+			"suspended_until": status.SuspendedUntil,
+			"reason":          status.Reason,
+		})
+	case AccountStatusBanned:
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error":  "Your account has been banned",
+			"code":   ErrorCodeAccountBanned,
+			"reason": status.Reason,
+		})
+	default:
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "This account no longer exists",
+			"code":  ErrorCodeAccountDeleted,
+		})
+	}
+}
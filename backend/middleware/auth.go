@@ -1,6 +1,8 @@
 package middleware
 
 import (
+	"marketplace-backend/config"
+	"marketplace-backend/models"
 	"net/http"
 	"os"
 	"strings"
@@ -10,7 +12,7 @@ import (
 	"github.com/google/uuid"
 )
 
-// AuthMiddleware validates JWT tokens
+// AuthMiddleware validates JWT tokens and turns away accounts that are suspended, banned or deleted
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
@@ -46,6 +48,18 @@ func AuthMiddleware() gin.HandlerFunc {
 		if claims, ok := token.Claims.(jwt.MapClaims); ok {
 			if userIDStr, ok := claims["user_id"].(string); ok {
 				if userID, err := uuid.Parse(userIDStr); err == nil {
+					// A valid token is not enough: the account may have been suspended, banned or deleted since
+					status, err := lookupAccountStatus(userID)
+					if err != nil {
+						c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check account status"})
+						c.Abort()
+						return
+					}
+					if status.Status != AccountStatusActive {
+						AbortWithAccountStatus(c, status)
+						return
+					}
+
 					c.Set("userID", userID)
 					c.Next()
 					return
@@ -58,7 +72,8 @@ func AuthMiddleware() gin.HandlerFunc {
 	}
 }
 
-// OptionalAuthMiddleware extracts user info if token is present but doesn't require it
+// OptionalAuthMiddleware extracts user info if token is present but doesn't require it.
+// Users whose account is not active are treated as anonymous.
 func OptionalAuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
@@ -84,7 +99,9 @@ func OptionalAuthMiddleware() gin.HandlerFunc {
 			if claims, ok := token.Claims.(jwt.MapClaims); ok {
 				if userIDStr, ok := claims["user_id"].(string); ok {
 					if userID, err := uuid.Parse(userIDStr); err == nil {
-						c.Set("userID", userID)
+						if status, err := lookupAccountStatus(userID); err == nil && status.Status == AccountStatusActive {
+							c.Set("userID", userID)
+						}
 					}
 				}
 			}
@@ -93,3 +110,25 @@ func OptionalAuthMiddleware() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// AdminMiddleware requires the authenticated user to be an admin.
+// It must run after AuthMiddleware.
+func AdminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userID")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			c.Abort()
+			return
+		}
+
+		var user models.User
+		if err := config.DB.First(&user, userID).Error; err != nil || !user.IsAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
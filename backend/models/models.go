@@ -30,6 +30,12 @@ type User struct {
 	College    College   `json:"college" gorm:"foreignKey:CollegeID"`
 	CreatedAt  time.Time `json:"created_at"`
 	UpdatedAt  time.Time `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"` // account deleted; the row is anonymized
+	PurgedAt       *time.Time     `json:"-"`              // set once the purge job has removed the remaining personal data
+	HiddenAt       *time.Time     `json:"-"`              // hidden by moderation: listings and storefront are not shown
+	SuspendedUntil *time.Time     `json:"-"`              // suspended by an admin: can't sign in and listings are hidden until then
+	BannedAt       *time.Time     `json:"-"`              // banned by an admin for good
+	StatusReason   string         `json:"-"`              // reason given for the current suspension or ban
 }
 
 // Product represents a marketplace item
@@ -40,15 +46,29 @@ type Product struct {
 	Description string    `json:"description"`
 	Images      string    `json:"images" gorm:"type:text"` // JSON string for now
 	Condition   string    `json:"condition" gorm:"not null"` // New, Like New, Good, Fair, For Parts
-	Category    string    `json:"category" gorm:"not null"`
-	Tags        string    `json:"tags" gorm:"type:text"` // JSON string for now
-	Status      string    `json:"status" gorm:"default:'available'"` // available, requested, sold
+	Category    string    `json:"category" gorm:"not null"` // name of CategoryID, kept denormalized for filters and older clients
+	CategoryID  *uuid.UUID `json:"category_id" gorm:"type:uuid;index"`
+	ISBN        string    `json:"isbn" gorm:"index"`        // ISBN-13 from the isbn attribute, for search
+	CourseCode  string    `json:"course_code" gorm:"index"` // normalized course_code attribute, e.g. MATH101
+	Attributes  string    `json:"attributes" gorm:"type:text"` // JSON object typed by the category's AttributeSchema, e.g. {"brand":"Dell","storage_gb":512}
+	Tags        string    `json:"tags" gorm:"type:text"` // JSON array of TagList names, kept in sync by config.SetProductTags
+	TagList     []Tag     `json:"-" gorm:"many2many:product_tags"`
+	Status      string    `json:"status" gorm:"default:'available'"` // draft, scheduled, available, requested, sold, expired, archived
+	PublishAt   *time.Time `json:"publish_at"`                       // when a scheduled listing goes live
+	PublishedAt *time.Time `json:"published_at" gorm:"index"`        // last time it went live or was bumped; listings are ordered by this
+	ExpiresAt   *time.Time `json:"expires_at" gorm:"index"`
+	ExpiryReminderSentAt *time.Time `json:"-"`
+	SoldAt      *time.Time `json:"sold_at"`
 	SellerID    uuid.UUID `json:"seller_id" gorm:"type:uuid;not null"`
 	Seller      User      `json:"seller" gorm:"foreignKey:SellerID"`
 	CollegeID   uuid.UUID `json:"college_id" gorm:"type:uuid;not null"`
 	College     College   `json:"college" gorm:"foreignKey:CollegeID"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"deleted_at" gorm:"index"` // soft delete; restorable within config.ProductRestoreWindowDays
+	DeletedByID *uuid.UUID `json:"-" gorm:"type:uuid"`           // seller or admin who removed the listing
+	RestoredAt  *time.Time `json:"restored_at"`
+	HiddenAt    *time.Time `json:"-" gorm:"index"` // hidden by moderation after reports; still visible to the seller
 }
 
 // Chat represents a conversation between users
@@ -60,6 +80,7 @@ type Chat struct {
 	IsAccepted        bool      `json:"is_accepted" gorm:"default:false"`
 	Participants []User    `json:"participants" gorm:"many2many:chat_participants;"`
 	Messages     []Message `json:"messages" gorm:"foreignKey:ChatID"`
+	ListingRemoved bool    `json:"listing_removed" gorm:"-"` // set when the product has been deleted
 	CollegeID    uuid.UUID `json:"college_id" gorm:"type:uuid;not null"`
 	College      College   `json:"college" gorm:"foreignKey:CollegeID"`
 	CreatedAt    time.Time `json:"created_at"`
@@ -74,6 +95,8 @@ type Message struct {
 	FromID    uuid.UUID `json:"from_id" gorm:"type:uuid;not null"`
 	From      User      `json:"from" gorm:"foreignKey:FromID"`
 	Text      string    `json:"text" gorm:"not null"`
+	IsSystem  bool      `json:"is_system" gorm:"default:false"` // Posted by the app on behalf of FromID
+	HiddenAt  *time.Time `json:"-"`                             // hidden by moderation after reports
 	CreatedAt time.Time `json:"created_at"`
 }
 
@@ -100,9 +123,297 @@ type Favorite struct {
 	User      User      `json:"user" gorm:"foreignKey:UserID"`
 	ProductID uuid.UUID `json:"product_id" gorm:"type:uuid;not null"`
 	Product   Product   `json:"product" gorm:"foreignKey:ProductID"`
+	// Price when the product was favorited, used to phrase price-drop alerts
+	PriceWhenFavorited float64   `json:"price_when_favorited"`
+	CreatedAt          time.Time `json:"created_at"`
+}
+
+// SafeExchangeSpot is a college-managed campus location approved for meetups
+type SafeExchangeSpot struct {
+	ID          uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	CollegeID   uuid.UUID `json:"college_id" gorm:"type:uuid;not null;index"`
+	Name        string    `json:"name" gorm:"not null"`
+	Description string    `json:"description"`
+	Address     string    `json:"address"`
+	IsActive    bool      `json:"is_active" gorm:"not null"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Meetup is a pickup proposal attached to an accepted purchase request
+type Meetup struct {
+	ID                uuid.UUID        `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	PurchaseRequestID uuid.UUID        `json:"purchase_request_id" gorm:"type:uuid;not null;uniqueIndex"`
+	PurchaseRequest   PurchaseRequest  `json:"-" gorm:"foreignKey:PurchaseRequestID"`
+	ChatID            uuid.UUID        `json:"chat_id" gorm:"type:uuid;not null"`
+	ProposedByID      uuid.UUID        `json:"proposed_by_id" gorm:"type:uuid;not null"`
+	LocationID        uuid.UUID        `json:"location_id" gorm:"type:uuid;not null"`
+	Location          SafeExchangeSpot `json:"location" gorm:"foreignKey:LocationID"`
+	Slots             []MeetupSlot     `json:"slots" gorm:"foreignKey:MeetupID;constraint:OnDelete:CASCADE"`
+	AcceptedSlotID    *uuid.UUID       `json:"accepted_slot_id" gorm:"type:uuid"`
+	Note              string           `json:"note"`
+	Status            string           `json:"status" gorm:"default:'proposed'"` // proposed, accepted, declined
+	CreatedAt         time.Time        `json:"created_at"`
+	UpdatedAt         time.Time        `json:"updated_at"`
+}
+
+// MeetupSlot is one candidate time window for a meetup
+type MeetupSlot struct {
+	ID       uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	MeetupID uuid.UUID `json:"meetup_id" gorm:"type:uuid;not null;index"`
+	StartsAt time.Time `json:"starts_at" gorm:"not null"`
+	EndsAt   time.Time `json:"ends_at" gorm:"not null"`
+}
+
+// Notification is an in-app notification shown in a user's notification center
+type Notification struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	Type      string     `json:"type" gorm:"not null"` // see handlers.NotificationEvent* constants
+	Title     string     `json:"title" gorm:"not null"`
+	Body      string     `json:"body"`
+	Link      string     `json:"link"` // Frontend route to open, e.g. /chats/<id>
+	ReadAt    *time.Time `json:"read_at"`
+	CreatedAt time.Time  `json:"created_at" gorm:"index"`
+}
+
+// NotificationPreference stores a user's opt-out for one event type.
+// A missing row means the defaults apply.
+type NotificationPreference struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_notification_pref_user_event"`
+	EventType string    `json:"event_type" gorm:"not null;uniqueIndex:idx_notification_pref_user_event"`
+	InApp     bool      `json:"in_app" gorm:"not null"`
+	Email     bool      `json:"email" gorm:"default:false"`
+	Push      bool      `json:"push" gorm:"not null;default:true"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// EmailJob is a queued outbound email, retried with backoff until sent or failed
+type EmailJob struct {
+	ID            uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID        uuid.UUID  `json:"user_id" gorm:"type:uuid;index"`
+	ToEmail       string     `json:"to_email" gorm:"not null"`
+	Subject       string     `json:"subject" gorm:"not null"`
+	HTMLBody      string     `json:"-" gorm:"type:text"`
+	TextBody      string     `json:"-" gorm:"type:text"`
+	Status        string     `json:"status" gorm:"default:'pending';index"` // pending, sending, sent, failed
+	Attempts      int        `json:"attempts" gorm:"default:0"`
+	NextAttemptAt time.Time  `json:"next_attempt_at" gorm:"index"`
+	LastError     string     `json:"last_error"`
+	SentAt        *time.Time `json:"sent_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// DigestCursor remembers when a user last received the daily digest
+type DigestCursor struct {
+	UserID     uuid.UUID `json:"user_id" gorm:"type:uuid;primary_key"`
+	LastSentAt time.Time `json:"last_sent_at"`
+}
+
+// PushSubscription is a browser Web Push subscription for one user device
+type PushSubscription struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID     uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	Endpoint   string     `json:"endpoint" gorm:"type:text;not null;uniqueIndex"`
+	P256dh     string     `json:"-" gorm:"not null"`
+	Auth       string     `json:"-" gorm:"not null"`
+	DeviceName string     `json:"device_name"`
+	UserAgent  string     `json:"user_agent"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// SavedSearch is a stored product listing query that alerts its owner about new matches
+type SavedSearch struct {
+	ID             uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID         uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	Name           string     `json:"name" gorm:"not null"`
+	Keywords       string     `json:"keywords"`
+	Category       string     `json:"category"`
+	MaxPrice       *float64   `json:"max_price"`
+	Condition      string     `json:"condition"`
+	ISBN           string     `json:"isbn"`
+	CourseCode     string     `json:"course_code"`
+	Attributes     string     `json:"attributes" gorm:"type:text"` // JSON object of attribute filters, see ProductFilter
+	Alerts         bool       `json:"alerts" gorm:"not null;default:true"`
+	LastNotifiedAt *time.Time `json:"last_notified_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// ProductPriceHistory records every price a product has been listed at
+type ProductPriceHistory struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ProductID uuid.UUID `json:"product_id" gorm:"type:uuid;not null;index"`
+	Price     float64   `json:"price" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
+
+// WishlistCollection is a named group of a user's favorited products
+type WishlistCollection struct {
+	ID          uuid.UUID        `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID      uuid.UUID        `json:"user_id" gorm:"type:uuid;not null;index"`
+	Name        string           `json:"name" gorm:"not null"`
+	Description string           `json:"description"`
+	ShareToken  *string          `json:"-" gorm:"uniqueIndex"` // Set while the collection has a public read-only link
+	Items       []CollectionItem `json:"items,omitempty" gorm:"foreignKey:CollectionID;constraint:OnDelete:CASCADE"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+}
+
+// CollectionItem places a product in a wishlist collection
+type CollectionItem struct {
+	ID           uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	CollectionID uuid.UUID `json:"collection_id" gorm:"type:uuid;not null;uniqueIndex:idx_collection_item"`
+	ProductID    uuid.UUID `json:"product_id" gorm:"type:uuid;not null;uniqueIndex:idx_collection_item"`
+	Product      Product   `json:"product" gorm:"foreignKey:ProductID"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// ProductView counts one viewer opening a product page on a given day
+type ProductView struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ProductID uuid.UUID `json:"product_id" gorm:"type:uuid;not null;uniqueIndex:idx_product_view_daily"`
+	ViewerKey string    `json:"-" gorm:"not null;uniqueIndex:idx_product_view_daily"` // user ID, or a hash of IP and user agent
+	Day       time.Time `json:"day" gorm:"type:date;not null;uniqueIndex:idx_product_view_daily"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Category is a node in the product taxonomy, e.g. Electronics > Laptops
+type Category struct {
+	ID                  uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ParentID            *uuid.UUID `json:"parent_id" gorm:"type:uuid;index"`
+	Name                string     `json:"name" gorm:"not null"`
+	Slug                string     `json:"slug" gorm:"not null;uniqueIndex"`
+	AttributeSchema     string     `json:"attribute_schema" gorm:"type:text"` // JSON array of AttributeDefinition
+	DescriptionTemplate string     `json:"description_template"`              // fallback description, %s is the title
+	SortOrder           int        `json:"sort_order" gorm:"not null;default:0"`
+	IsActive            bool       `json:"is_active" gorm:"not null"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+// AttributeDefinition describes one typed listing attribute in a category's AttributeSchema
+type AttributeDefinition struct {
+	Key      string   `json:"key"`
+	Label    string   `json:"label"`
+	Type     string   `json:"type"` // string, integer, number, boolean, enum
+	Required bool     `json:"required"`
+	Options  []string `json:"options,omitempty"` // allowed values for enum
+	Min      *float64 `json:"min,omitempty"`     // bounds for integer and number
+	Max      *float64 `json:"max,omitempty"`
+}
+
+// Tag is a normalized (lowercase, trimmed) listing tag shared across products
+type Tag struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Name      string    `json:"name" gorm:"not null;uniqueIndex"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// ProductImport is a bulk listing import processed in the background; Report holds one ProductImportRow per row
+type ProductImport struct {
+	ID            uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID        uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	Filename      string     `json:"filename"`
+	Format        string     `json:"format"`                                // csv, json
+	Status        string     `json:"status" gorm:"default:'pending';index"` // pending, processing, completed, failed
+	TotalRows     int        `json:"total_rows"`
+	ProcessedRows int        `json:"processed_rows"`
+	SucceededRows int        `json:"succeeded_rows"`
+	FailedRows    int        `json:"failed_rows"`
+	Report        string     `json:"-" gorm:"type:text"`
+	Error         string     `json:"error,omitempty"`
+	CompletedAt   *time.Time `json:"completed_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// ProductImportRow is the outcome of one row of a ProductImport
+type ProductImportRow struct {
+	Row       int        `json:"row"` // 1-based, not counting the CSV header
+	Title     string     `json:"title"`
+	Status    string     `json:"status"` // created, failed
+	ProductID *uuid.UUID `json:"product_id,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// DataExport is an archive of everything a user has stored with us, built in the background
+type DataExport struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID      uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	Status      string     `json:"status" gorm:"default:'pending';index"` // pending, processing, ready, failed, expired
+	BlobName    string     `json:"-"`                                     // in config.ExportsContainer
+	SizeBytes   int64      `json:"size_bytes"`
+	Error       string     `json:"error,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at"` // the archive and its download link are removed after this
+	CompletedAt *time.Time `json:"completed_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// SellerReview is a buyer's rating of the seller after an accepted purchase request, at most one per request
+type SellerReview struct {
+	ID                uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	PurchaseRequestID uuid.UUID `json:"purchase_request_id" gorm:"type:uuid;not null;uniqueIndex"`
+	SellerID          uuid.UUID `json:"seller_id" gorm:"type:uuid;not null;index"`
+	BuyerID           uuid.UUID `json:"buyer_id" gorm:"type:uuid;not null"`
+	Rating            int       `json:"rating" gorm:"not null"` // 1 to 5
+	Comment           string    `json:"comment"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// Follow subscribes a user to a seller's new listings in their feed
+type Follow struct {
+	ID         uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	FollowerID uuid.UUID `json:"follower_id" gorm:"type:uuid;not null;uniqueIndex:idx_follow"`
+	SellerID   uuid.UUID `json:"seller_id" gorm:"type:uuid;not null;uniqueIndex:idx_follow;index"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Block stops two users from messaging, requesting from or seeing the listings of each other
+type Block struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	BlockerID uuid.UUID `json:"blocker_id" gorm:"type:uuid;not null;uniqueIndex:idx_block"`
+	BlockedID uuid.UUID `json:"blocked_id" gorm:"type:uuid;not null;uniqueIndex:idx_block;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Report flags a product, user or message for moderation; each user can report a target once
+type Report struct {
+	ID           uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ReporterID   uuid.UUID  `json:"reporter_id" gorm:"type:uuid;not null;uniqueIndex:idx_report"`
+	TargetType   string     `json:"target_type" gorm:"not null;uniqueIndex:idx_report;index:idx_report_target"` // product, user, message
+	TargetID     uuid.UUID  `json:"target_id" gorm:"type:uuid;not null;uniqueIndex:idx_report;index:idx_report_target"`
+	Reason       string     `json:"reason" gorm:"not null"` // spam, scam, harassment, inappropriate, prohibited_item, counterfeit, other
+	Details      string     `json:"details"`
+	Status       string     `json:"status" gorm:"default:'open';index"` // open, resolved, dismissed
+	ResolvedByID *uuid.UUID `json:"resolved_by_id" gorm:"type:uuid"`
+	ResolvedAt   *time.Time `json:"resolved_at"`
+	Resolution   string     `json:"resolution"` // moderator's note
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// AuditLog records an admin action or a sensitive account change. Rows are append-only: a database
+// trigger rejects updates and deletes.
+type AuditLog struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ActorID    *uuid.UUID `json:"actor_id" gorm:"type:uuid;index"` // nil for actions taken by the system
+	Action     string     `json:"action" gorm:"not null;index"`    // e.g. report.resolved, account.email_changed
+	TargetType string     `json:"target_type" gorm:"index:idx_audit_target"`
+	TargetID   *uuid.UUID `json:"target_id" gorm:"type:uuid;index:idx_audit_target"`
+	Changes    string     `json:"-" gorm:"type:text"` // JSON object of changed fields: {"field": {"before": ..., "after": ...}}
+	Note       string     `json:"note"`
+	IP         string     `json:"ip"`
+	UserAgent  string     `json:"user_agent"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"index"`
+}
+
 // BeforeCreate hooks for UUID generation
 func (u *User) BeforeCreate(tx *gorm.DB) error {
 	if u.ID == uuid.Nil {
@@ -152,3 +463,150 @@ func (c *College) BeforeCreate(tx *gorm.DB) error {
 	}
 	return nil
 }
+
+func (s *SafeExchangeSpot) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+func (m *Meetup) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return nil
+}
+
+func (s *MeetupSlot) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+func (n *Notification) BeforeCreate(tx *gorm.DB) error {
+	if n.ID == uuid.Nil {
+		n.ID = uuid.New()
+	}
+	return nil
+}
+
+func (p *NotificationPreference) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+func (j *EmailJob) BeforeCreate(tx *gorm.DB) error {
+	if j.ID == uuid.Nil {
+		j.ID = uuid.New()
+	}
+	return nil
+}
+
+func (s *PushSubscription) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+func (s *SavedSearch) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+func (h *ProductPriceHistory) BeforeCreate(tx *gorm.DB) error {
+	if h.ID == uuid.Nil {
+		h.ID = uuid.New()
+	}
+	return nil
+}
+
+func (w *WishlistCollection) BeforeCreate(tx *gorm.DB) error {
+	if w.ID == uuid.Nil {
+		w.ID = uuid.New()
+	}
+	return nil
+}
+
+func (i *CollectionItem) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return nil
+}
+
+func (v *ProductView) BeforeCreate(tx *gorm.DB) error {
+	if v.ID == uuid.Nil {
+		v.ID = uuid.New()
+	}
+	return nil
+}
+
+func (c *Category) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
+
+func (t *Tag) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
+func (i *ProductImport) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return nil
+}
+
+func (e *DataExport) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+func (r *SellerReview) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+func (f *Follow) BeforeCreate(tx *gorm.DB) error {
+	if f.ID == uuid.Nil {
+		f.ID = uuid.New()
+	}
+	return nil
+}
+
+func (b *Block) BeforeCreate(tx *gorm.DB) error {
+	if b.ID == uuid.Nil {
+		b.ID = uuid.New()
+	}
+	return nil
+}
+
+func (r *Report) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+func (l *AuditLog) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == uuid.Nil {
+		l.ID = uuid.New()
+	}
+	return nil
+}
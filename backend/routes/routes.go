@@ -1,6 +1,8 @@
 package routes
 
 import (
+	"os"
+
 	"marketplace-backend/handlers"
 	"marketplace-backend/middleware"
 
@@ -17,6 +19,8 @@ func SetupRoutes(r *gin.Engine) {
 			auth.POST("/register", handlers.Register)
 			auth.POST("/login", handlers.Login)
 			auth.GET("/me", middleware.AuthMiddleware(), handlers.GetMe)
+			auth.PUT("/password", middleware.AuthMiddleware(), handlers.ChangePassword)
+			auth.PUT("/email", middleware.AuthMiddleware(), handlers.ChangeEmail)
 		}
 
 		// Products routes
@@ -24,15 +28,38 @@ func SetupRoutes(r *gin.Engine) {
 		{
 			products.GET("", middleware.OptionalAuthMiddleware(), handlers.GetProducts)
 			products.POST("", middleware.AuthMiddleware(), handlers.CreateProduct)
+			products.POST("/import", middleware.AuthMiddleware(), handlers.ImportProducts)
+			products.GET("/import/:id", middleware.AuthMiddleware(), handlers.GetProductImport)
 			products.GET("/:id", middleware.OptionalAuthMiddleware(), handlers.GetProduct)
+			products.GET("/:id/price-history", handlers.GetProductPriceHistory)
 			products.PUT("/:id", middleware.AuthMiddleware(), handlers.UpdateProduct)
+			products.PATCH("/:id", middleware.AuthMiddleware(), handlers.PatchProduct)
+			products.POST("/:id/publish", middleware.AuthMiddleware(), handlers.PublishProduct)
+			products.POST("/:id/relist", middleware.AuthMiddleware(), handlers.RelistProduct)
 			products.DELETE("/:id", middleware.AuthMiddleware(), handlers.DeleteProduct)
+			products.POST("/:id/restore", middleware.AuthMiddleware(), handlers.RestoreProduct)
 			
 			// AI-powered description generation
 			products.POST("/generate-description", middleware.AuthMiddleware(), handlers.GenerateDescription)
 			products.POST("/generate-description-with-files", middleware.AuthMiddleware(), handlers.GenerateDescriptionWithFiles)
 		}
 
+		// Category taxonomy routes (managed by admins)
+		categories := api.Group("/categories")
+		{
+			categories.GET("", middleware.OptionalAuthMiddleware(), handlers.GetCategories)
+			categories.GET("/:id", handlers.GetCategory)
+			categories.POST("", middleware.AuthMiddleware(), middleware.AdminMiddleware(), handlers.CreateCategory)
+			categories.PUT("/:id", middleware.AuthMiddleware(), middleware.AdminMiddleware(), handlers.UpdateCategory)
+			categories.DELETE("/:id", middleware.AuthMiddleware(), middleware.AdminMiddleware(), handlers.DeleteCategory)
+		}
+
+		// ISBN validation and book metadata
+		api.GET("/books/isbn/:isbn", handlers.LookupISBN)
+
+		// Tag autocomplete
+		api.GET("/tags", middleware.AuthMiddleware(), handlers.GetTags)
+
 		// AI services routes
 		ai := api.Group("/ai")
 		{
@@ -40,16 +67,46 @@ func SetupRoutes(r *gin.Engine) {
 			ai.POST("/test-upload", handlers.TestFileUpload)
 		}
 
+		// Personalized feed of new listings
+		api.GET("/feed", middleware.AuthMiddleware(), handlers.GetFeed)
+
 		// Users routes
 		users := api.Group("/users")
 		{
-			users.GET("/:id", handlers.GetUser)
+			users.GET("/me/listings", middleware.AuthMiddleware(), handlers.GetMyListings)
+			users.GET("/me/listings/stats", middleware.AuthMiddleware(), handlers.GetMyListingStats)
+			users.GET("/me/export", middleware.AuthMiddleware(), handlers.GetMyDataExport)
+			users.GET("/me/following", middleware.AuthMiddleware(), handlers.GetFollowing)
+			users.GET("/me/blocked", middleware.AuthMiddleware(), handlers.GetBlockedUsers)
+			users.DELETE("/me", middleware.AuthMiddleware(), handlers.DeleteMyAccount)
+			users.GET("/:id", middleware.OptionalAuthMiddleware(), handlers.GetUser)
+			users.GET("/:id/storefront", middleware.OptionalAuthMiddleware(), handlers.GetStorefront)
+			users.POST("/:id/follow", middleware.AuthMiddleware(), handlers.FollowSeller)
+			users.DELETE("/:id/follow", middleware.AuthMiddleware(), handlers.UnfollowSeller)
+			users.POST("/:id/block", middleware.AuthMiddleware(), handlers.BlockUser)
+			users.DELETE("/:id/block", middleware.AuthMiddleware(), handlers.UnblockUser)
 			users.POST("", handlers.CreateUser)
 			users.PUT("/:id", middleware.AuthMiddleware(), handlers.UpdateUser)
+			users.POST("/:id/avatar", middleware.AuthMiddleware(), handlers.UploadUserAvatar)
+		}
+
+		// Reports of products, users and messages
+		api.POST("/reports", middleware.AuthMiddleware(), handlers.CreateReport)
+
+		// Moderation queue (admins)
+		moderation := api.Group("/moderation", middleware.AuthMiddleware(), middleware.AdminMiddleware())
+		{
+			moderation.GET("/reports", handlers.GetModerationQueue)
+			moderation.POST("/reports/:id/resolve", handlers.ResolveReport)
+			moderation.POST("/reports/:id/dismiss", handlers.DismissReport)
+			moderation.POST("/users/:id/suspend", handlers.SuspendUser)
+			moderation.POST("/users/:id/ban", handlers.BanUser)
+			moderation.POST("/users/:id/reinstate", handlers.ReinstateUser)
+			moderation.GET("/audit-logs", handlers.GetAuditLogs)
 		}
 
 		// Chats routes
-		chats := api.Group("/chats")
+		chats := api.Group("/chats", middleware.AuthMiddleware())
 		{
 			chats.GET("", handlers.GetChats)
 			chats.GET("/:id", handlers.GetChat)
@@ -58,19 +115,93 @@ func SetupRoutes(r *gin.Engine) {
 		}
 
 		// Purchase requests routes
-		requests := api.Group("/requests")
+		requests := api.Group("/requests", middleware.AuthMiddleware())
 		{
 			requests.GET("", handlers.GetPurchaseRequests)
 			requests.POST("", handlers.CreatePurchaseRequest)
 			requests.PUT("/:id", handlers.UpdatePurchaseRequest)
+			requests.GET("/:id/meetup", handlers.GetMeetup)
+			requests.POST("/:id/meetup", handlers.ProposeMeetup)
+			requests.POST("/:id/review", handlers.CreateSellerReview)
+		}
+
+		// Meetup scheduling routes
+		meetups := api.Group("/meetups", middleware.AuthMiddleware())
+		{
+			meetups.POST("/:id/accept", handlers.AcceptMeetup)
+			meetups.POST("/:id/decline", handlers.DeclineMeetup)
+			meetups.POST("/:id/reschedule", handlers.RescheduleMeetup)
+			meetups.GET("/:id/calendar.ics", handlers.GetMeetupCalendar)
+		}
+
+		// Notification center routes
+		notifications := api.Group("/notifications", middleware.AuthMiddleware())
+		{
+			notifications.GET("", handlers.GetNotifications)
+			notifications.POST("/:id/read", handlers.MarkNotificationRead)
+			notifications.POST("/read-all", handlers.MarkAllNotificationsRead)
+			notifications.GET("/preferences", handlers.GetNotificationPreferences)
+			notifications.PUT("/preferences", handlers.UpdateNotificationPreferences)
+		}
+
+		// Web Push routes
+		push := api.Group("/push")
+		{
+			push.GET("/vapid-public-key", handlers.GetVAPIDPublicKey)
+			push.GET("/subscriptions", middleware.AuthMiddleware(), handlers.GetPushSubscriptions)
+			push.POST("/subscriptions", middleware.AuthMiddleware(), handlers.CreatePushSubscription)
+			push.DELETE("/subscriptions/:id", middleware.AuthMiddleware(), handlers.DeletePushSubscription)
+		}
+
+		// Fake push service for local and integration testing only
+		if os.Getenv("WEBPUSH_FAKE_ENDPOINT") == "true" {
+			fakePush := api.Group("/dev/push")
+			{
+				fakePush.POST("/:token", handlers.FakePushServiceReceive)
+				fakePush.GET("/:token", handlers.FakePushServiceMessages)
+			}
+		}
+
+		// Saved searches routes
+		savedSearches := api.Group("/saved-searches", middleware.AuthMiddleware())
+		{
+			savedSearches.GET("", handlers.GetSavedSearches)
+			savedSearches.POST("", handlers.CreateSavedSearch)
+			savedSearches.GET("/:id", handlers.GetSavedSearch)
+			savedSearches.PUT("/:id", handlers.UpdateSavedSearch)
+			savedSearches.DELETE("/:id", handlers.DeleteSavedSearch)
+		}
+
+		// Safe exchange spots (managed by college admins)
+		spots := api.Group("/meetup-spots", middleware.AuthMiddleware())
+		{
+			spots.GET("", handlers.GetSafeExchangeSpots)
+			spots.POST("", middleware.AdminMiddleware(), handlers.CreateSafeExchangeSpot)
+			spots.PUT("/:id", middleware.AdminMiddleware(), handlers.UpdateSafeExchangeSpot)
+			spots.DELETE("/:id", middleware.AdminMiddleware(), handlers.DeleteSafeExchangeSpot)
 		}
 
 		// Favorites routes
-		favorites := api.Group("/favorites")
+		favorites := api.Group("/favorites", middleware.AuthMiddleware())
 		{
 			favorites.GET("", handlers.GetFavorites)
 			favorites.POST("/:id", handlers.CreateFavorite)
 			favorites.DELETE("/:id", handlers.DeleteFavorite)
 		}
+
+		// Wishlist collections routes
+		collections := api.Group("/collections")
+		{
+			collections.GET("/shared/:token", handlers.GetSharedCollection)
+			collections.GET("", middleware.AuthMiddleware(), handlers.GetCollections)
+			collections.POST("", middleware.AuthMiddleware(), handlers.CreateCollection)
+			collections.GET("/:id", middleware.AuthMiddleware(), handlers.GetCollection)
+			collections.PUT("/:id", middleware.AuthMiddleware(), handlers.UpdateCollection)
+			collections.DELETE("/:id", middleware.AuthMiddleware(), handlers.DeleteCollection)
+			collections.POST("/:id/items/:productId", middleware.AuthMiddleware(), handlers.AddCollectionItem)
+			collections.DELETE("/:id/items/:productId", middleware.AuthMiddleware(), handlers.RemoveCollectionItem)
+			collections.POST("/:id/share", middleware.AuthMiddleware(), handlers.ShareCollection)
+			collections.DELETE("/:id/share", middleware.AuthMiddleware(), handlers.UnshareCollection)
+		}
 	}
 }
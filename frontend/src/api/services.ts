@@ -47,11 +47,9 @@ export const purchaseRequestsAPI = {
 
 // Favorites API
 export const favoritesAPI = {
-  getByUser: (userId: string) => api.get(`/favorites?user_id=${userId}`),
-  add: (productId: string, userId: string) => 
-    api.post(`/favorites/${productId}`, { user_id: userId }),
-  remove: (productId: string, userId: string) => 
-    api.delete(`/favorites/${productId}?user_id=${userId}`),
+  getMine: () => api.get('/favorites'),
+  add: (productId: string) => api.post(`/favorites/${productId}`),
+  remove: (productId: string) => api.delete(`/favorites/${productId}`),
 }
 
 // Auth API
@@ -86,7 +86,7 @@ export const MarketplaceProvider = ({ children }: { children: ReactNode }) => {
             setUser(userData)
             
             // Load user's favorites
-            const favoritesResponse = await favoritesAPI.getByUser(userData.id)
+            const favoritesResponse = await favoritesAPI.getMine()
             const userFavorites = favoritesResponse.data.map((fav: any) => fav.product_id)
             setFavorites(userFavorites)
           } catch (error) {
@@ -242,10 +242,10 @@ export const MarketplaceProvider = ({ children }: { children: ReactNode }) => {
     try {
       const isFavorited = favorites.includes(productId)
       if (isFavorited) {
-        await favoritesAPI.remove(productId, user.id)
+        await favoritesAPI.remove(productId)
         setFavorites((prev) => prev.filter((id) => id !== productId))
       } else {
-        await favoritesAPI.add(productId, user.id)
+        await favoritesAPI.add(productId)
         setFavorites((prev) => [...prev, productId])
       }
     } catch (error) {
@@ -78,6 +78,13 @@ resource "azurerm_storage_container" "images" {
   container_access_type = "blob" # Public access to blobs
 }
 
+# Private container for user data exports, downloaded through short-lived SAS links
+resource "azurerm_storage_container" "exports" {
+  name                  = "exports"
+  storage_account_name  = azurerm_storage_account.marketplace_storage.name
+  container_access_type = "private"
+}
+
 # Static Web App created manually in Azure Portal
 # Name: swa-marketplace-dev
 